@@ -1,6 +1,7 @@
 package hdf5utils
 
 import (
+	"context"
 	"encoding/gob"
 	"errors"
 	"fmt"
@@ -15,6 +16,18 @@ import (
 	"syscall"
 )
 
+func init() {
+	//concrete types of HdfData.Buffer sent through the gob encoded async pipe
+	for _, buffer := range []interface{}{
+		&[]float32{}, &[]float64{},
+		&[]int8{}, &[]int16{}, &[]int32{}, &[]int64{},
+		&[]uint8{}, &[]uint16{}, &[]uint32{}, &[]uint64{},
+		&[]bool{}, &[]string{},
+	} {
+		gob.Register(buffer)
+	}
+}
+
 type AsyncInput struct {
 	Filepath  string
 	Datapath  string
@@ -23,18 +36,90 @@ type AsyncInput struct {
 }
 
 func RunAsync(hdfType string, input AsyncInput, dset interface{}, wg *sync.WaitGroup) error {
+	proc, err := asyncCommand(context.Background(), hdfType, input)
+	if err != nil {
+		logger().Error("async command failed", "file", input.Filepath, "datapath", input.Datapath, "error", err)
+		if wg != nil {
+			wg.Done()
+		}
+		return err
+	}
+	err = proc.Start()
+	if err != nil {
+		logger().Error("async command failed to start", "file", input.Filepath, "datapath", input.Datapath, "error", err)
+	}
+
+	file, err := os.OpenFile(input.Namedpipe, os.O_CREATE, os.ModeNamedPipe)
+	if err != nil {
+		logger().Error("unable to open pipe", "pipe", input.Namedpipe, "error", err)
+	}
+	logger().Debug("opened pipe", "pipe", input.Namedpipe)
+	dec := gob.NewDecoder(file)
+	err = dec.Decode(dset)
+	if err == io.EOF {
+		logger().Debug("async read finished with EOF", "pipe", input.Namedpipe)
+	} else if err != nil {
+		logger().Error("async read failed", "file", input.Filepath, "datapath", input.Datapath, "pipe", input.Namedpipe, "error", err)
+	}
+	logger().Debug("finished async read", "file", input.Filepath, "datapath", input.Datapath, "pipe", input.Namedpipe)
+	if wg != nil {
+		wg.Done()
+	}
+	proc.Wait()
+
+	return err
+}
+
+// RunAsyncContext runs the hdfutil child process and decodes its result into dset.
+// If ctx is cancelled or its deadline passes the child process is killed and ctx.Err() is returned.
+func RunAsyncContext(ctx context.Context, hdfType string, input AsyncInput, dset interface{}) error {
+	proc, err := asyncCommand(ctx, hdfType, input)
+	if err != nil {
+		return err
+	}
+	err = proc.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		file, err := os.OpenFile(input.Namedpipe, os.O_RDONLY, os.ModeNamedPipe)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer file.Close()
+		done <- gob.NewDecoder(file).Decode(dset)
+	}()
+
+	select {
+	case err = <-done:
+		proc.Wait()
+		return err
+	case <-ctx.Done():
+		//the child is killed by CommandContext. Briefly open the write side of the pipe
+		//so a reader still blocked on open or read sees EOF and exits.
+		if w, werr := os.OpenFile(input.Namedpipe, os.O_RDWR, os.ModeNamedPipe); werr == nil {
+			w.Close()
+		}
+		proc.Wait()
+		return ctx.Err()
+	}
+}
+
+// asyncCommand creates the named pipe and the (unstarted) hdfutil child process for an async read
+func asyncCommand(ctx context.Context, hdfType string, input AsyncInput) (*exec.Cmd, error) {
 	//var raslibCmd string = "/workspaces/model-library/go-raslib/raslib"
 	hdfutilCmd := os.Getenv("HDFDUTILCMD")
 	if hdfutilCmd == "" {
-		log.Println("Missing path to raslib executable")
-		return errors.New("Missing path to raslib executable")
+		return nil, errors.New("Missing path to raslib executable")
 	}
 
 	os.Remove(input.Namedpipe)
-	var err error
-	err = syscall.Mkfifo(input.Namedpipe, 0666)
+	err := syscall.Mkfifo(input.Namedpipe, 0666)
 	if err != nil {
-		log.Printf("Failed to create pipe %s: %s\n", input.Namedpipe, err) //@TODO  might want to bail at this point!!!!!
+		return nil, fmt.Errorf("Failed to create pipe %s: %s", input.Namedpipe, err)
 	}
 
 	env := []string{
@@ -51,39 +136,18 @@ func RunAsync(hdfType string, input AsyncInput, dset interface{}, wg *sync.WaitG
 	switch hdfType {
 	case "DSET": //dataset
 		env = append(env, varsToEnv(input.Vars)...)
-		proc = exec.Command(hdfutilCmd, hdfType)
+		proc = exec.CommandContext(ctx, hdfutilCmd, hdfType)
 	case "ATTR": //dataset attributes
 		env = append(env, varsToEnv(input.Vars)...)
-		proc = exec.Command(hdfutilCmd, hdfType)
+		proc = exec.CommandContext(ctx, hdfutilCmd, hdfType)
+	default:
+		return nil, fmt.Errorf("invalid async type: %s", hdfType)
 	}
 
 	proc.Stdout = os.Stdout
 	proc.Stderr = os.Stderr
 	proc.Env = env
-	err = proc.Start()
-	if err != nil {
-		log.Println(err)
-	}
-
-	file, err := os.OpenFile(input.Namedpipe, os.O_CREATE, os.ModeNamedPipe)
-	if err != nil {
-		log.Println(err)
-	}
-	log.Printf("Opened Pipe %s\n", input.Namedpipe)
-	dec := gob.NewDecoder(file)
-	err = dec.Decode(dset)
-	if err == io.EOF {
-		log.Println("Finished with EOF")
-	} else if err != nil {
-		log.Printf("Finished with err: %s\n", err)
-	}
-	log.Printf("Finished async call for pipe %s\n", input.Namedpipe)
-	if wg != nil {
-		wg.Done()
-	}
-	proc.Wait()
-
-	return err
+	return proc, nil
 }
 
 func varsToEnv(vars map[string]string) []string {
@@ -101,13 +165,9 @@ func handleAsync() {
 	hdfFilePath := os.Getenv("FILEPATH")
 	hdfDataPath := os.Getenv("DATAPATH")
 
-	fmt.Printf("Filepath: %s\n", hdfFilePath)
-	fmt.Printf("Datapath: %s\n", hdfDataPath)
-	fmt.Println(os.Environ())
-
 	switch hdfType {
 	case "DSET":
-		fmt.Println("Starting HDFDataset Read")
+		logger().Debug("starting async dataset read", "file", hdfFilePath, "datapath", hdfDataPath)
 		AsyncHdfRead(hdfFilePath, namedpipe, hdfDataPath)
 		//@TODO enable async attr reading....
 		// case "ATTR":
@@ -124,7 +184,7 @@ func handleAsync() {
 	}
 }
 
-//@TODO force errors in async reads and make sure child process is fully closed and errors and somehow handled
+// @TODO force errors in async reads and make sure child process is fully closed and errors and somehow handled
 func AsyncHdfRead(url string, namedpipe string, datapath string) {
 	options, err := getOptions()
 	if err != nil {
@@ -142,6 +202,9 @@ func AsyncHdfRead(url string, namedpipe string, datapath string) {
 		subset := os.Getenv("HDFSUBSET")
 		if subset != "" {
 			rowrange, colrange, err := csvToRanges(subset)
+			if err != nil {
+				log.Fatalf("invalid data subset '%s': %s\n", subset, err)
+			}
 			err = data.ReadSubset(rowrange, colrange)
 			if err != nil {
 				log.Fatalf("error reading data subset: %s\n", err)
@@ -197,6 +260,12 @@ func getOptions() (HdfReadOptions, error) {
 		Dtype:        reflect.Kind(typeInt),
 		ReadOnCreate: true,
 	}
+	if strsizes := os.Getenv("HDFOPTSTRSIZES"); strsizes != "" {
+		err = options.Strsizes.FromString(strsizes)
+		if err != nil {
+			return HdfReadOptions{}, err
+		}
+	}
 	return options, nil
 }
 
@@ -211,7 +280,6 @@ func csvToRanges(csv string) ([]int, []int, error) {
 			}
 			ranges[i] = vi
 		}
-		fmt.Println(ranges)
 		return ranges[0:2], ranges[2:4], nil
 	}
 	return nil, nil, errors.New("Invalid range")
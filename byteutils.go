@@ -26,3 +26,35 @@ func I16fb(bytes []byte) int16 {
 	bits := binary.LittleEndian.Uint16(bytes)
 	return int16(bits)
 }
+
+// Ifb decodes a little endian integer of 1, 2, 4 or 8 bytes
+func Ifb(bytes []byte, signed bool) int64 {
+	switch len(bytes) {
+	case 1:
+		if signed {
+			return int64(int8(bytes[0]))
+		}
+		return int64(bytes[0])
+	case 2:
+		if signed {
+			return int64(I16fb(bytes))
+		}
+		return int64(binary.LittleEndian.Uint16(bytes))
+	case 4:
+		if signed {
+			return int64(I32fb(bytes))
+		}
+		return int64(binary.LittleEndian.Uint32(bytes))
+	default:
+		return int64(binary.LittleEndian.Uint64(bytes))
+	}
+}
+
+// reverseBytes returns a copy of bytes in the opposite byte order
+func reverseBytes(bytes []byte) []byte {
+	reversed := make([]byte, len(bytes))
+	for i, b := range bytes {
+		reversed[len(bytes)-1-i] = b
+	}
+	return reversed
+}
@@ -1,7 +1,6 @@
 package hdf5utils
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"reflect"
@@ -16,7 +15,7 @@ const stringLengthTag = "strlen"
 type UnpackFunction func(b []byte) reflect.Value
 
 func ReadCompoundAttributes(f *hdf5.File, attrpath string, data interface{}, uf UnpackFunction) error {
-	dset, err := f.OpenDataset(attrpath)
+	dset, err := openDataset(f, attrpath)
 	if err != nil {
 		return err
 	}
@@ -73,6 +72,31 @@ func unpack(t reflect.Type, b []byte, metadata CompoundAttributeMetadata) (refle
 	bytecnt := 0
 	for _, v := range metadata.UT {
 		field := newval.Field(v.TargetIndex)
+		if v.Enum != nil {
+			value := v.Enum.decode(b[bytecnt : bytecnt+v.Len])
+			switch {
+			case v.EnumNames:
+				name, ok := v.Enum.Name(value)
+				if !ok {
+					return newval, fmt.Errorf("value %d of field %s is not a member of the enum: %w", value, t.Field(v.TargetIndex).Name, ErrOutOfRange)
+				}
+				field.SetString(name)
+			case isSignedKind(field.Kind()):
+				field.SetInt(value)
+			default:
+				field.SetUint(uint64(value))
+			}
+			bytecnt += v.Len
+			continue
+		}
+		if v.Array != nil {
+			err := v.Array.unpack(field, b[bytecnt:bytecnt+v.Len])
+			if err != nil {
+				return newval, fmt.Errorf("field %s: %w", t.Field(v.TargetIndex).Name, err)
+			}
+			bytecnt += v.Len
+			continue
+		}
 		switch field.Type().Kind() {
 		case reflect.Uint8:
 			field.SetUint(uint64(b[bytecnt : bytecnt+1][0]))
@@ -85,10 +109,13 @@ func unpack(t reflect.Type, b []byte, metadata CompoundAttributeMetadata) (refle
 			bytecnt += 4
 		case reflect.Float64:
 			field.SetFloat(float64(F64fb(b[bytecnt : bytecnt+8])))
-			bytecnt += 4
+			bytecnt += 8
 		case reflect.String:
 			stl := v.Len
-			strval := string(bytes.Trim(b[bytecnt:bytecnt+stl], "\x00")) //slice and strip out all null character values
+			strval := string(b[bytecnt : bytecnt+stl])
+			if !v.Raw {
+				strval = v.Format.Decode(b[bytecnt : bytecnt+stl])
+			}
 			newval.Field(v.TargetIndex).SetString(strval)
 			bytecnt += stl
 		}
@@ -107,19 +134,47 @@ type FieldMetadata struct {
 	FieldName  string
 	FieldIndex int
 	FieldType  reflect.Kind
+	Type       reflect.Type
 	StringSize int
 	HdfName    string
+	Raw        bool   //keep string padding, set with the hdf tag option "raw"
+	Enum       string //how enum members are read, "name" or "value", set with the hdf tag option "enum"
 }
 
 type unpackTable struct {
 	ValueIndex  int
 	TargetIndex int
 	Len         int
+	Format      StringFormat
+	Raw         bool
+	Enum        *HdfEnum     //set for enum members
+	EnumNames   bool         //read enum members as their names
+	Array       *ArrayMember //set for array members
+}
+
+// ArrayMember describes a compound member of the HDF5 array class
+type ArrayMember struct {
+	Dims      []int        //dimensions of the array
+	Kind      reflect.Kind //Go kind of the array elements
+	ElemSize  int          //byte size of an element
+	BigEndian bool         //byte order of the elements in the file
+}
+
+// Len returns the number of elements in the array
+func (a *ArrayMember) Len() int {
+	n := 1
+	for _, d := range a.Dims {
+		n *= d
+	}
+	return n
 }
 
 type CompoundAttributeMetadata struct {
 	NumFields  int
 	FieldNames []string
+	Formats    []StringFormat //string format of each member, the zero format for non string members
+	Enums      []*HdfEnum     //name/value mapping of each enum member, nil for other members
+	Arrays     []*ArrayMember //dimensions and element kind of each array member, nil for other members
 	Dest       []FieldMetadata
 	UT         []unpackTable
 }
@@ -132,8 +187,43 @@ func compoundAttrMetadata(dset *hdf5.Dataset, dest reflect.Type) (CompoundAttrib
 	ctype := hdf5.CompoundType{*typ}
 	nm := ctype.NMembers()
 	names := make([]string, nm)
+	formats := make([]StringFormat, nm)
+	enums := make([]*HdfEnum, nm)
+	arrays := make([]*ArrayMember, nm)
 	for i := 0; i < nm; i++ {
 		names[i] = strings.TrimSpace(ctype.MemberName(i))
+		switch ctype.MemberClass(i) {
+		case hdf5.T_STRING:
+			mtype, err := ctype.MemberType(i)
+			if err != nil {
+				return CompoundAttributeMetadata{}, err
+			}
+			formats[i], err = stringFormat(mtype.ID())
+			mtype.Close()
+			if err != nil {
+				return CompoundAttributeMetadata{}, err
+			}
+		case hdf5.T_ENUM:
+			mtype, err := ctype.MemberType(i)
+			if err != nil {
+				return CompoundAttributeMetadata{}, err
+			}
+			enums[i], err = readEnum(mtype)
+			mtype.Close()
+			if err != nil {
+				return CompoundAttributeMetadata{}, err
+			}
+		case hdf5.T_ARRAY:
+			mtype, err := ctype.MemberType(i)
+			if err != nil {
+				return CompoundAttributeMetadata{}, err
+			}
+			arrays[i], err = readArrayMember(mtype)
+			mtype.Close()
+			if err != nil {
+				return CompoundAttributeMetadata{}, fmt.Errorf("member '%s': %w", names[i], err)
+			}
+		}
 	}
 
 	numDestfields := dest.NumField()
@@ -145,9 +235,16 @@ func compoundAttrMetadata(dset *hdf5.Dataset, dest reflect.Type) (CompoundAttrib
 			FieldName:  f.Name,
 			FieldIndex: j,
 			FieldType:  f.Type.Kind(),
+			Type:       f.Type,
 		}
 		if hdf, ok := f.Tag.Lookup("hdf"); ok {
-			fm.HdfName = hdf
+			name, opts := parseHdfTag(hdf)
+			fm.HdfName = name
+			_, fm.Raw = opts["raw"]
+			fm.Enum = opts["enum"]
+			if fm.Enum != "" && fm.Enum != "name" && fm.Enum != "value" {
+				return CompoundAttributeMetadata{}, fmt.Errorf("field %s: invalid enum option '%s', expected name or value", f.Name, fm.Enum)
+			}
 		}
 		if strlen, ok := f.Tag.Lookup("strlen"); ok {
 			strsize, err := strconv.Atoi(strlen)
@@ -162,12 +259,30 @@ func compoundAttrMetadata(dset *hdf5.Dataset, dest reflect.Type) (CompoundAttrib
 	cam := CompoundAttributeMetadata{
 		NumFields:  nm,
 		FieldNames: names,
+		Formats:    formats,
+		Enums:      enums,
+		Arrays:     arrays,
 		Dest:       fieldMetadata,
 	}
 	err = cam.BuildUnpackTable()
 	return cam, err
 }
 
+// parseHdfTag splits an hdf struct tag such as "name,raw" or "name,enum=name" into the member name and options
+func parseHdfTag(tag string) (string, map[string]string) {
+	parts := strings.Split(tag, ",")
+	opts := map[string]string{}
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 {
+			opts[kv[0]] = kv[1]
+		} else {
+			opts[kv[0]] = ""
+		}
+	}
+	return parts[0], opts
+}
+
 func (cam *CompoundAttributeMetadata) destType(hdfName string) (FieldMetadata, error) {
 	for _, v := range cam.Dest {
 		if v.HdfName == hdfName {
@@ -184,12 +299,161 @@ func (cam *CompoundAttributeMetadata) BuildUnpackTable() error {
 		if err != nil {
 			return err
 		}
-		ut = append(ut, unpackTable{i, fm.FieldIndex, typeSize(fm)})
+		var enum *HdfEnum
+		if i < len(cam.Enums) {
+			enum = cam.Enums[i]
+		}
+		if enum != nil {
+			entry, err := enumUnpackTable(i, fm, enum)
+			if err != nil {
+				return err
+			}
+			ut = append(ut, entry)
+			continue
+		}
+		if i < len(cam.Arrays) && cam.Arrays[i] != nil {
+			entry, err := arrayUnpackTable(i, fm, cam.Arrays[i])
+			if err != nil {
+				return err
+			}
+			ut = append(ut, entry)
+			continue
+		}
+		if fm.Enum != "" {
+			return fmt.Errorf("field %s has an enum option but member '%s' is not an enum: %w", fm.FieldName, fn, ErrDatatypeMismatch)
+		}
+		if _, ok := typesize[fm.FieldType]; !ok && fm.FieldType != reflect.String {
+			return fmt.Errorf("field %s of kind %s: %w", fm.FieldName, fm.FieldType, ErrUnsupportedDatatype)
+		}
+		var format StringFormat
+		if i < len(cam.Formats) {
+			format = cam.Formats[i]
+		}
+		ut = append(ut, unpackTable{i, fm.FieldIndex, typeSize(fm), format, fm.Raw, nil, false, nil})
 	}
 	cam.UT = ut
 	return nil
 }
 
+// enumUnpackTable binds an enum member to a string field read as member names, or to an
+// integer field read as values
+func enumUnpackTable(member int, fm FieldMetadata, enum *HdfEnum) (unpackTable, error) {
+	names := fm.Enum == "name"
+	switch {
+	case names && fm.FieldType != reflect.String:
+		return unpackTable{}, fmt.Errorf("field %s of kind %s cannot hold enum names: %w", fm.FieldName, fm.FieldType, ErrDatatypeMismatch)
+	case !names && fm.FieldType == reflect.String:
+		return unpackTable{}, fmt.Errorf("field %s is a string bound to an enum member, add the hdf tag option enum=name: %w", fm.FieldName, ErrDatatypeMismatch)
+	case !names && !isIntKind(fm.FieldType):
+		return unpackTable{}, fmt.Errorf("field %s of kind %s cannot hold enum values: %w", fm.FieldName, fm.FieldType, ErrUnsupportedDatatype)
+	case !names && !enumFits(fm.FieldType, enum):
+		return unpackTable{}, fmt.Errorf("field %s of kind %s cannot hold the values of a %d byte enum: %w", fm.FieldName, fm.FieldType, enum.Size, ErrDatatypeMismatch)
+	}
+	return unpackTable{member, fm.FieldIndex, int(enum.Size), StringFormat{}, false, enum, names, nil}, nil
+}
+
+// enumFits reports whether every value of enum can be stored in an integer of kind without
+// changing the value, the same rule checkConversion applies to integer datasets
+func enumFits(kind reflect.Kind, enum *HdfEnum) bool {
+	size := kindSizes[kind]
+	if isSignedKind(kind) {
+		return size > enum.Size || (enum.Signed && size == enum.Size)
+	}
+	return !enum.Signed && size >= enum.Size
+}
+
+// readArrayMember reads the dimensions and element type of an array datatype.  Arrays of
+// integers and floats are supported.
+func readArrayMember(dtype *hdf5.Datatype) (*ArrayMember, error) {
+	atype := hdf5.ArrayType{*dtype}
+	class, size, signed, err := arrayBase(dtype.ID())
+	if err != nil {
+		return nil, err
+	}
+	kind := numericKind(class, size, signed)
+	if kind == reflect.Invalid {
+		return nil, fmt.Errorf("arrays of %d byte %s elements: %w", size, typeClassName(class), ErrUnsupportedDatatype)
+	}
+	bigEndian, err := typeBigEndian(dtype.ID())
+	if err != nil {
+		return nil, err
+	}
+	var dims []int
+	if atype.NDims() > 0 {
+		dims = atype.ArrayDims()
+	}
+	if len(dims) == 0 {
+		return nil, errors.New("unable to get the array dimensions")
+	}
+	return &ArrayMember{dims, kind, int(size), bigEndian}, nil
+}
+
+// arrayUnpackTable binds an array member to a Go array field with the same shape, nesting
+// arrays for each dimension, or to a slice field holding the flattened elements
+func arrayUnpackTable(member int, fm FieldMetadata, array *ArrayMember) (unpackTable, error) {
+	ft := fm.Type
+	for _, d := range array.Dims {
+		if ft.Kind() == reflect.Slice {
+			break
+		}
+		if ft.Kind() != reflect.Array || ft.Len() != d {
+			return unpackTable{}, fmt.Errorf("field %s of type %s does not match the array dimensions %v: %w", fm.FieldName, fm.Type, array.Dims, ErrDatatypeMismatch)
+		}
+		ft = ft.Elem()
+	}
+	if ft.Kind() == reflect.Slice {
+		ft = ft.Elem()
+	}
+	if ft.Kind() != array.Kind {
+		return unpackTable{}, fmt.Errorf("field %s with %s elements is bound to an array of %s: %w", fm.FieldName, ft.Kind(), array.Kind, ErrDatatypeMismatch)
+	}
+	return unpackTable{member, fm.FieldIndex, array.Len() * array.ElemSize, StringFormat{}, false, nil, false, array}, nil
+}
+
+// unpack sets the array field v from the elements in b, stored in the byte order of the file
+func (a *ArrayMember) unpack(v reflect.Value, b []byte) error {
+	switch v.Kind() {
+	case reflect.Array:
+		if v.Len() == 0 {
+			return nil
+		}
+		n := len(b) / v.Len()
+		for i := 0; i < v.Len(); i++ {
+			err := a.unpack(v.Index(i), b[i*n:(i+1)*n])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		n := len(b) / a.ElemSize
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			err := a.unpack(v.Index(i), b[i*a.ElemSize:(i+1)*a.ElemSize])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if a.BigEndian {
+		b = reverseBytes(b)
+	}
+	switch v.Kind() {
+	case reflect.Float32:
+		v.SetFloat(float64(F32fb(b)))
+	case reflect.Float64:
+		v.SetFloat(F64fb(b))
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(Ifb(b, true))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(Ifb(b, false)))
+	default:
+		return fmt.Errorf("array elements of kind %s: %w", v.Kind(), ErrUnsupportedDatatype)
+	}
+	return nil
+}
+
 func (cam *CompoundAttributeMetadata) PackedSize() int {
 	var size int
 	for _, v := range cam.UT {
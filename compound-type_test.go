@@ -0,0 +1,213 @@
+package hdf5utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+type scalarRecord struct {
+	Scale  float64
+	Offset float64
+	Code   int32
+}
+
+type arrayRecord struct {
+	Scale  float64
+	Matrix [2][3]float64
+	Pair   []float64
+	Code   int32
+}
+
+// float64Bytes encodes values little endian
+func float64Bytes(values ...float64) []byte {
+	b := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(b[i*8:], math.Float64bits(v))
+	}
+	return b
+}
+
+func TestUnpackFloat64(t *testing.T) {
+	metadata := CompoundAttributeMetadata{UT: []unpackTable{
+		{ValueIndex: 0, TargetIndex: 0, Len: 8},
+		{ValueIndex: 1, TargetIndex: 1, Len: 8},
+		{ValueIndex: 2, TargetIndex: 2, Len: 4},
+	}}
+	b := float64Bytes(0.5, -1.5)
+	b = append(b, 7, 0, 0, 0)
+
+	v, err := unpack(reflect.TypeOf(scalarRecord{}), b, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := scalarRecord{0.5, -1.5, 7}
+	if got := v.Interface().(scalarRecord); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func arrayRecordMetadata(t *testing.T) CompoundAttributeMetadata {
+	t.Helper()
+	typ := reflect.TypeOf(arrayRecord{})
+	field := func(i int) FieldMetadata {
+		f := typ.Field(i)
+		return FieldMetadata{FieldName: f.Name, FieldIndex: i, FieldType: f.Type.Kind(), Type: f.Type}
+	}
+	matrix, err := arrayUnpackTable(1, field(1), &ArrayMember{[]int{2, 3}, reflect.Float64, 8, false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := arrayUnpackTable(2, field(2), &ArrayMember{[]int{2}, reflect.Float64, 8, false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return CompoundAttributeMetadata{UT: []unpackTable{
+		{ValueIndex: 0, TargetIndex: 0, Len: 8},
+		matrix,
+		pair,
+		{ValueIndex: 3, TargetIndex: 3, Len: 4},
+	}}
+}
+
+func TestUnpackFloat64Arrays(t *testing.T) {
+	metadata := arrayRecordMetadata(t)
+	if size := metadata.PackedSize(); size != 8+48+16+4 {
+		t.Fatalf("got packed size %d, want %d", size, 8+48+16+4)
+	}
+	b := float64Bytes(0.5, 1, 2, 3, 4, 5, 6, -1.5, 2.5)
+	b = append(b, 7, 0, 0, 0)
+
+	v, err := unpack(reflect.TypeOf(arrayRecord{}), b, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := arrayRecord{0.5, [2][3]float64{{1, 2, 3}, {4, 5, 6}}, []float64{-1.5, 2.5}, 7}
+	if got := v.Interface().(arrayRecord); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+type arrayAttributes struct {
+	Scale  float64       `hdf:"Scale"`
+	Matrix [2][3]float64 `hdf:"Matrix"`
+	Pair   []int16       `hdf:"Pair"`
+	Code   int32         `hdf:"Code"`
+}
+
+// arrayFixture writes a compound dataset with a 2x3 float64 array member and a 2 element
+// big endian int16 array member to a new file and returns the open file
+func arrayFixture(t *testing.T) *hdf5.File {
+	t.Helper()
+	f := newFixture(t, "arrays.h5")
+
+	matrix, err := hdf5.NewArrayType(hdf5.T_IEEE_F64LE, []int{2, 3})
+	must(t, err)
+	defer matrix.Close()
+	pair, err := hdf5.NewArrayType(hdf5.T_STD_I16BE, []int{2})
+	must(t, err)
+	defer pair.Close()
+	ctype, err := hdf5.NewCompoundType(64)
+	must(t, err)
+	defer ctype.Close()
+	must(t, ctype.Insert("Scale", 0, hdf5.T_IEEE_F64LE))
+	must(t, ctype.Insert("Matrix", 8, &matrix.Datatype))
+	must(t, ctype.Insert("Pair", 56, &pair.Datatype))
+	must(t, ctype.Insert("Code", 60, hdf5.T_STD_I32LE))
+
+	space, err := hdf5.CreateSimpleDataspace([]uint{2}, nil)
+	must(t, err)
+	defer space.Close()
+	dset, err := f.CreateDataset("/attributes", &ctype.Datatype, space)
+	must(t, err)
+	defer dset.Close()
+
+	raw := []byte{}
+	for i, pair := range [][]byte{{0xff, 0xfe, 0x01, 0x02}, {0x00, 0x03, 0x80, 0x00}} {
+		record := float64Bytes(float64(i)+0.5, 1, 2, 3, 4, 5, float64(6*i+6))
+		record = append(record, pair...)
+		code := make([]byte, 4)
+		binary.LittleEndian.PutUint32(code, uint32(int32(7-10*i)))
+		record = append(record, code...)
+		raw = append(raw, record...)
+	}
+	must(t, dset.Write(&raw))
+	return f
+}
+
+func TestReadCompoundArrays(t *testing.T) {
+	f := arrayFixture(t)
+	var records []arrayAttributes
+	if err := ReadCompoundAttributes(f, "/attributes", &records, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []arrayAttributes{
+		{0.5, [2][3]float64{{1, 2, 3}, {4, 5, 6}}, []int16{-2, 258}, 7},
+		{1.5, [2][3]float64{{1, 2, 3}, {4, 5, 12}}, []int16{3, -32768}, -3},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %+v, want %+v", records, want)
+	}
+
+	var wrongShape []struct {
+		Scale  float64       `hdf:"Scale"`
+		Matrix [3][2]float64 `hdf:"Matrix"`
+		Pair   []int16       `hdf:"Pair"`
+		Code   int32         `hdf:"Code"`
+	}
+	if err := ReadCompoundAttributes(f, "/attributes", &wrongShape, nil); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("3x2 field for a 2x3 array: got %v, want ErrDatatypeMismatch", err)
+	}
+}
+
+func TestArrayUnpackTableShape(t *testing.T) {
+	array := &ArrayMember{[]int{2, 3}, reflect.Float64, 8, false}
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf([3][2]float64{}),
+		reflect.TypeOf([2][3]float32{}),
+		reflect.TypeOf([6]float64{}),
+		reflect.TypeOf(float64(0)),
+	} {
+		fm := FieldMetadata{FieldName: "Matrix", Type: typ, FieldType: typ.Kind()}
+		if _, err := arrayUnpackTable(0, fm, array); !errors.Is(err, ErrDatatypeMismatch) {
+			t.Errorf("%s: got %v, want ErrDatatypeMismatch", typ, err)
+		}
+	}
+	for _, typ := range []reflect.Type{reflect.TypeOf([2][3]float64{}), reflect.TypeOf([2][]float64{}), reflect.TypeOf([]float64{})} {
+		fm := FieldMetadata{FieldName: "Matrix", Type: typ, FieldType: typ.Kind()}
+		entry, err := arrayUnpackTable(0, fm, array)
+		if err != nil {
+			t.Errorf("%s: %v", typ, err)
+		} else if entry.Len != 48 {
+			t.Errorf("%s: got length %d, want 48", typ, entry.Len)
+		}
+	}
+}
+
+func TestUnpackBigEndianArray(t *testing.T) {
+	b := make([]byte, 8*3)
+	for i, v := range []float64{1.5, -2, 1e10} {
+		binary.BigEndian.PutUint64(b[i*8:], math.Float64bits(v))
+	}
+	var floats [3]float64
+	array := &ArrayMember{[]int{3}, reflect.Float64, 8, true}
+	if err := array.unpack(reflect.ValueOf(&floats).Elem(), b); err != nil {
+		t.Fatal(err)
+	}
+	if want := [3]float64{1.5, -2, 1e10}; floats != want {
+		t.Errorf("got %v, want %v", floats, want)
+	}
+
+	var ints []int16
+	array = &ArrayMember{[]int{2}, reflect.Int16, 2, true}
+	if err := array.unpack(reflect.ValueOf(&ints).Elem(), []byte{0xff, 0xfe, 0x01, 0x02}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int16{-2, 258}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("got %v, want %v", ints, want)
+	}
+}
@@ -0,0 +1,289 @@
+package hdf5utils
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// HdfContextReader is implemented by readers that honor cancellation and deadlines
+type HdfContextReader interface {
+	HdfReader
+	ReadContext(ctx context.Context) (*HdfData, error)
+	ReadIntoContext(ctx context.Context, dest interface{}) error
+	ReadSubsetContext(ctx context.Context, rowrange []int, colrange []int) (*HdfData, error)
+	ReadSubsetIntoContext(ctx context.Context, dest interface{}, rowrange []int, colrange []int) error
+}
+
+// runWithContext runs fn in the background and returns ctx.Err() if the context ends first.
+// HDF5 calls cannot be interrupted, so an abandoned call still runs to completion in the background.
+func runWithContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OpenFileContext opens a file like OpenFileWithOptions, returning ctx.Err() if the context
+// ends before the open completes.  Retries of remote opens stop when the context ends and a
+// file opened after cancellation is closed.
+func OpenFileContext(ctx context.Context, url string, options HdfFileOptions) (*hdf5.File, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		f   *hdf5.File
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		f, err := openFile(ctx, url, options)
+		done <- result{f, err}
+	}()
+	select {
+	case r := <-done:
+		return r.f, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.err == nil {
+				r.f.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+///////////////////////////////////////////////////////
+/////////////////////HDF Reader Sync//////////////////
+
+// the context variants serialize on h.busy with the other reader methods, so a call
+// abandoned by a cancelled context finishes before the next call or Close on the same reader starts
+
+func (h *HdfReaderSync) ReadContext(ctx context.Context) (*HdfData, error) {
+	var data *HdfData
+	err := h.runExclusive(ctx, func() (err error) {
+		data, err = h.read(ctx)
+		return err
+	})
+	return data, err
+}
+
+func (h *HdfReaderSync) ReadIntoContext(ctx context.Context, dest interface{}) error {
+	return h.readIntoPrivate(ctx, dest, func(buf interface{}) error {
+		return h.readInto(ctx, buf)
+	})
+}
+
+func (h *HdfReaderSync) ReadSubsetContext(ctx context.Context, rowrange []int, colrange []int) (*HdfData, error) {
+	var data *HdfData
+	err := h.runExclusive(ctx, func() (err error) {
+		data, err = h.readSubset(ctx, rowrange, colrange)
+		return err
+	})
+	return data, err
+}
+
+func (h *HdfReaderSync) ReadSubsetIntoContext(ctx context.Context, dest interface{}, rowrange []int, colrange []int) error {
+	return h.readIntoPrivate(ctx, dest, func(buf interface{}) error {
+		return h.readSubsetInto(ctx, buf, rowrange, colrange)
+	})
+}
+
+// readIntoPrivate runs read on a new buffer of the type and length of dest and copies it into
+// dest once the read completes, so a read abandoned by a cancelled context never writes to dest.
+// The read converts kinds and fills struct and enum name destinations exactly like ReadInto.
+func (h *HdfReaderSync) readIntoPrivate(ctx context.Context, dest interface{}, read func(buf interface{}) error) error {
+	buf, err := privateBuffer(dest)
+	if err != nil {
+		return err
+	}
+	err = h.runExclusive(ctx, func() error {
+		return read(buf)
+	})
+	if err != nil {
+		return err
+	}
+	return copyBuffer(dest, buf)
+}
+
+func (h *HdfReaderSync) runExclusive(ctx context.Context, fn func() error) error {
+	return runWithContext(ctx, func() error {
+		h.busy.Lock()
+		defer h.busy.Unlock()
+		return fn()
+	})
+}
+
+///////////////////////////////////////////////////////
+/////////////////////HDF Reader Async/////////////////
+
+func (h *HdfReaderAsync) ReadContext(ctx context.Context) (*HdfData, error) {
+	data, err := h.readContext(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	h.dims = data.Dims
+	return data, nil
+}
+
+// ReadIntoContext reads the dataset in the child process and copies the result into dest
+func (h *HdfReaderAsync) ReadIntoContext(ctx context.Context, dest interface{}) error {
+	data, err := h.ReadContext(ctx)
+	if err != nil {
+		return err
+	}
+	return copyBuffer(dest, data.Buffer)
+}
+
+func (h *HdfReaderAsync) ReadSubsetContext(ctx context.Context, rowrange []int, colrange []int) (*HdfData, error) {
+	h.subset = subsetCsv(rowrange, colrange)
+	data, err := h.readContext(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	h.dims = data.Dims
+	return data, nil
+}
+
+// ReadSubsetIntoContext reads a subset in the child process and copies the result into dest
+func (h *HdfReaderAsync) ReadSubsetIntoContext(ctx context.Context, dest interface{}, rowrange []int, colrange []int) error {
+	data, err := h.ReadSubsetContext(ctx, rowrange, colrange)
+	if err != nil {
+		return err
+	}
+	return copyBuffer(dest, data.Buffer)
+}
+
+func (h *HdfReaderAsync) readContext(ctx context.Context, dimsOnly bool) (*HdfData, error) {
+	pipe, err := GetPipe()
+	if err != nil {
+		return nil, err
+	}
+	defer ClosePipes([]string{pipe})
+
+	var data HdfData
+	err = RunAsyncContext(ctx, "DSET", h.asyncInput(pipe, dimsOnly), &data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// privateBuffer returns a new slice, or pointer to a slice, of the type and length of dest
+func privateBuffer(dest interface{}) (interface{}, error) {
+	v := reflect.ValueOf(dest)
+	switch {
+	case v.Kind() == reflect.Slice:
+		return reflect.MakeSlice(v.Type(), v.Len(), v.Len()).Interface(), nil
+	case v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice:
+		p := reflect.New(v.Elem().Type())
+		p.Elem().Set(reflect.MakeSlice(v.Elem().Type(), v.Elem().Len(), v.Elem().Len()))
+		return p.Interface(), nil
+	}
+	return nil, fmt.Errorf("unable to read into %T, expected a slice or a pointer to a slice: %w", dest, ErrUnsupportedDatatype)
+}
+
+// copyBuffer copies a buffer decoded from the child process, or read privately, into dest.  Pointers to
+// slices are replaced, slices must have the length of the buffer.
+func copyBuffer(dest interface{}, buffer interface{}) error {
+	src := reflect.Indirect(reflect.ValueOf(buffer))
+	dst := reflect.ValueOf(dest)
+	if src.Kind() != reflect.Slice || !dst.IsValid() {
+		return fmt.Errorf("unable to read a %T buffer into %T: %w", buffer, dest, ErrDatatypeMismatch)
+	}
+	if dst.Kind() == reflect.Ptr && dst.Elem().Kind() == reflect.Slice && dst.Elem().Type() == src.Type() {
+		dst.Elem().Set(src)
+		return nil
+	}
+	if dst.Kind() != reflect.Slice || dst.Type() != src.Type() {
+		return fmt.Errorf("unable to read a %T buffer into %T: %w", buffer, dest, ErrDatatypeMismatch)
+	}
+	if dst.Len() != src.Len() {
+		return fmt.Errorf("destination length %d does not match the %d elements read: %w", dst.Len(), src.Len(), ErrDatatypeMismatch)
+	}
+	reflect.Copy(dst, src)
+	return nil
+}
+
+///////////////////////////////////////////////////////
+/////////////////////HDF Dataset//////////////////////
+
+func (h *HdfDataset) ReadContext(ctx context.Context) error {
+	data, err := h.contextReader().ReadContext(ctx)
+	if err != nil {
+		return err
+	}
+	h.Data = data
+	h.dsetdims = data.Dims
+	return nil
+}
+
+func (h *HdfDataset) ReadSubsetContext(ctx context.Context, rowrange []int, colrange []int) error {
+	data, err := h.contextReader().ReadSubsetContext(ctx, rowrange, colrange)
+	if err != nil {
+		return err
+	}
+	h.Data = data
+	h.dsetdims = data.Dims
+	return nil
+}
+
+func (h *HdfDataset) ReadIntoContext(ctx context.Context, dest interface{}) error {
+	return h.contextReader().ReadIntoContext(ctx, dest)
+}
+
+func (h *HdfDataset) ReadSubsetIntoContext(ctx context.Context, dest interface{}, rowrange []int, colrange []int) error {
+	return h.contextReader().ReadSubsetIntoContext(ctx, dest, rowrange, colrange)
+}
+
+// contextReader returns the dataset reader, wrapping readers without context support
+// so the context is only checked before the call starts
+func (h *HdfDataset) contextReader() HdfContextReader {
+	if cr, ok := h.Reader.(HdfContextReader); ok {
+		return cr
+	}
+	return noContextReader{h.Reader}
+}
+
+type noContextReader struct {
+	HdfReader
+}
+
+func (r noContextReader) ReadContext(ctx context.Context) (*HdfData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.Read()
+}
+
+func (r noContextReader) ReadIntoContext(ctx context.Context, dest interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.ReadInto(dest)
+}
+
+func (r noContextReader) ReadSubsetContext(ctx context.Context, rowrange []int, colrange []int) (*HdfData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.ReadSubset(rowrange, colrange)
+}
+
+func (r noContextReader) ReadSubsetIntoContext(ctx context.Context, dest interface{}, rowrange []int, colrange []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.ReadSubsetInto(dest, rowrange, colrange)
+}
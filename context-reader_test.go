@@ -0,0 +1,145 @@
+package hdf5utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCopyBuffer(t *testing.T) {
+	buffer := &[]float64{1, 2, 3}
+
+	var replaced []float64
+	if err := copyBuffer(&replaced, buffer); err != nil || !reflect.DeepEqual(replaced, *buffer) {
+		t.Errorf("got %v, %v", replaced, err)
+	}
+	copied := make([]float64, 3)
+	if err := copyBuffer(copied, buffer); err != nil || !reflect.DeepEqual(copied, *buffer) {
+		t.Errorf("got %v, %v", copied, err)
+	}
+	for _, dest := range []interface{}{make([]float64, 2), &[]float32{}, []int32{0, 0, 0}, nil} {
+		if err := copyBuffer(dest, buffer); !errors.Is(err, ErrDatatypeMismatch) {
+			t.Errorf("copy into %T: got %v, want ErrDatatypeMismatch", dest, err)
+		}
+	}
+}
+
+func TestReadContextSync(t *testing.T) {
+	values := []float64{1, 2, 3, 4}
+	path := fixtureFile(t, "/values", &values, []uint{4})
+	reader, err := NewHdfReaderSync("/values", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	cr := reader.(HdfContextReader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cr.ReadContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	data, err := cr.ReadContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *data.Buffer.(*[]float64); !reflect.DeepEqual(got, values) {
+		t.Errorf("got %v, want %v", got, values)
+	}
+	dest := make([]float64, 2)
+	if err := cr.ReadSubsetIntoContext(context.Background(), dest, []int{1, 2}, []int{0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dest, values[1:3]) {
+		t.Errorf("got %v, want %v", dest, values[1:3])
+	}
+}
+
+func TestReadIntoContextAbandoned(t *testing.T) {
+	values := []float64{1, 2, 3, 4}
+	path := fixtureFile(t, "/values", &values, []uint{4})
+	reader, err := NewHdfReaderSync("/values", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	sr := reader.(*HdfReaderSync)
+
+	//hold the reader so the reads are still waiting when their contexts end
+	sr.busy.Lock()
+	dest := make([]float64, 4)
+	subset := make([]float64, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sr.ReadIntoContext(ctx, dest); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("read into: got %v, want context.DeadlineExceeded", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sr.ReadSubsetIntoContext(ctx, subset, []int{1, 2}, []int{0, 0}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("read subset into: got %v, want context.DeadlineExceeded", err)
+	}
+	sr.busy.Unlock()
+
+	//let the abandoned reads finish, they must not write to the destinations
+	time.Sleep(50 * time.Millisecond)
+	sr.busy.Lock()
+	sr.busy.Unlock()
+	if !reflect.DeepEqual(dest, make([]float64, 4)) || !reflect.DeepEqual(subset, make([]float64, 2)) {
+		t.Errorf("abandoned reads wrote %v and %v", dest, subset)
+	}
+
+	if err := sr.ReadIntoContext(context.Background(), dest); err != nil || !reflect.DeepEqual(dest, values) {
+		t.Errorf("got %v, %v, want %v", dest, err, values)
+	}
+}
+
+func TestReadIntoContextConversion(t *testing.T) {
+	values := []int16{-1, 2, 300}
+	path := fixtureFile(t, "/values", &values, []uint{3})
+	reader, err := NewHdfReaderSync("/values", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	cr := reader.(HdfContextReader)
+
+	//the private buffer is read with the same kind conversion as ReadInto
+	floats := make([]float64, 3)
+	if err := cr.ReadIntoContext(context.Background(), floats); err != nil || !reflect.DeepEqual(floats, []float64{-1, 2, 300}) {
+		t.Errorf("read int16 into []float64: got %v, %v", floats, err)
+	}
+	ints := []int64{0, 0}
+	if err := cr.ReadSubsetIntoContext(context.Background(), &ints, []int{1, 2}, []int{0, 0}); err != nil || !reflect.DeepEqual(ints, []int64{2, 300}) {
+		t.Errorf("read an int16 subset into *[]int64: got %v, %v", ints, err)
+	}
+	if err := cr.ReadIntoContext(context.Background(), 3); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("read into an int: got %v, want ErrUnsupportedDatatype", err)
+	}
+}
+
+func TestRunAsyncContextCancel(t *testing.T) {
+	//the child never writes to the pipe, so only killing it ends the read
+	dir := t.TempDir()
+	child := filepath.Join(dir, "hdfutil")
+	must(t, os.WriteFile(child, []byte("#!/bin/sh\nexec sleep 30\n"), 0755))
+	t.Setenv("HDFDUTILCMD", child)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	input := AsyncInput{Filepath: "file.h5", Datapath: "/values", Namedpipe: filepath.Join(dir, "pipe")}
+	start := time.Now()
+	var data HdfData
+	if err := RunAsyncContext(ctx, "DSET", input, &data); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	//RunAsyncContext waits for the child, so returning early means it was killed
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("returned after %s, the child was not killed", elapsed)
+	}
+}
@@ -0,0 +1,157 @@
+package hdf5utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// target size in bytes of automatically sized chunks
+const defaultChunkBytes = 1024 * 1024
+
+// initial row count assumed for extendable or empty datasets when sizing chunks
+const defaultChunkRows = 1024
+
+// HdfCreateOptions configures the dataset creation property list of new datasets.
+// Setting any filter enables chunking.
+type HdfCreateOptions struct {
+	ChunkDims  []uint      //chunk shape, sized automatically when empty
+	Deflate    int         //gzip compression level 1-9, 0 disables compression
+	Shuffle    bool        //byte shuffle filter, improves compression of numeric data
+	Fletcher32 bool        //checksum each chunk
+	FillValue  interface{} //value of unwritten elements, converted to the dataset type
+}
+
+func (o HdfCreateOptions) chunked() bool {
+	return len(o.ChunkDims) > 0 || o.Deflate > 0 || o.Shuffle || o.Fletcher32
+}
+
+// datasetCreatePropList builds the creation property list for a dataset with the given extent
+func datasetCreatePropList(options HdfCreateOptions, dims []uint, maxdims []uint, dtype *hdf5.Datatype, kind reflect.Kind) (*hdf5.PropList, error) {
+	dcpl, err := hdf5.NewPropList(hdf5.P_DATASET_CREATE)
+	if err != nil {
+		return nil, err
+	}
+	err = configureDatasetCreate(dcpl, options, dims, maxdims, dtype, kind)
+	if err != nil {
+		dcpl.Close()
+		return nil, err
+	}
+	return dcpl, nil
+}
+
+func configureDatasetCreate(dcpl *hdf5.PropList, options HdfCreateOptions, dims []uint, maxdims []uint, dtype *hdf5.Datatype, kind reflect.Kind) error {
+	if options.Deflate < 0 || options.Deflate > 9 {
+		return fmt.Errorf("invalid deflate level %d, expected 0-9", options.Deflate)
+	}
+	extendable := false
+	for _, m := range maxdims {
+		if m == unlimitedDim {
+			extendable = true
+		}
+	}
+
+	if extendable || options.chunked() {
+		chunk := options.ChunkDims
+		if len(chunk) == 0 {
+			chunk = autoChunk(dims, maxdims, int(dtype.Size()))
+		}
+		err := checkChunk(chunk, maxdims)
+		if err != nil {
+			return err
+		}
+		err = dcpl.SetChunk(chunk)
+		if err != nil {
+			return err
+		}
+	}
+
+	//filters are applied in the order they are added.  The checksum comes first so it
+	//covers the uncompressed data, as in h5py.
+	if options.Fletcher32 {
+		if err := setFletcher32(dcpl.ID()); err != nil {
+			return err
+		}
+	}
+	if options.Shuffle {
+		if err := setShuffle(dcpl.ID()); err != nil {
+			return err
+		}
+	}
+	if options.Deflate > 0 {
+		if err := dcpl.SetDeflate(options.Deflate); err != nil {
+			return err
+		}
+	}
+
+	if options.FillValue != nil {
+		t, ok := kindTypes[kind]
+		if !ok {
+			return fmt.Errorf("fill values are not supported for %s datasets: %w", kind, ErrUnsupportedDatatype)
+		}
+		fv := reflect.ValueOf(options.FillValue)
+		if !fv.Type().ConvertibleTo(t) {
+			return fmt.Errorf("fill value of type %T cannot be converted to %s: %w", options.FillValue, t, ErrUnsupportedDatatype)
+		}
+		val := reflect.New(t)
+		val.Elem().Set(fv.Convert(t))
+		if err := setFillValue(dcpl.ID(), dtype.ID(), val.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkChunk(chunk []uint, maxdims []uint) error {
+	if len(chunk) != len(maxdims) {
+		return fmt.Errorf("chunk rank %d does not match the dataset rank %d", len(chunk), len(maxdims))
+	}
+	for i, c := range chunk {
+		if c == 0 {
+			return errors.New("chunk dimensions must be greater than zero")
+		}
+		if maxdims[i] != unlimitedDim && c > maxdims[i] {
+			return fmt.Errorf("chunk dimension %d (%d) exceeds the dataset dimension %d", i, c, maxdims[i])
+		}
+	}
+	return nil
+}
+
+// autoChunk starts from the dataset shape (assuming defaultChunkRows for extendable
+// or empty dimensions) and alternately halves each dimension until the chunk fits in
+// defaultChunkBytes.  For 2-D time-by-cell arrays this keeps chunks balanced so reading
+// a single time step or a single cell's time series both touch a limited number of chunks.
+func autoChunk(dims []uint, maxdims []uint, elemsize int) []uint {
+	chunk := make([]uint, len(dims))
+	for i, d := range dims {
+		switch {
+		case maxdims[i] == unlimitedDim && d < defaultChunkRows:
+			chunk[i] = defaultChunkRows
+		case d == 0:
+			chunk[i] = 1
+		default:
+			chunk[i] = d
+		}
+	}
+	if elemsize < 1 {
+		elemsize = 1
+	}
+	for i := 0; ; i = (i + 1) % len(chunk) {
+		if int(arraySize(chunk))*elemsize <= defaultChunkBytes {
+			break
+		}
+		allOnes := true
+		for _, c := range chunk {
+			if c > 1 {
+				allOnes = false
+			}
+		}
+		if allOnes {
+			break
+		}
+		chunk[i] = (chunk[i] + 1) / 2
+	}
+	return chunk
+}
@@ -0,0 +1,121 @@
+package hdf5utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+func TestAutoChunk(t *testing.T) {
+	tests := []struct {
+		dims     []uint
+		maxdims  []uint
+		elemsize int
+		want     []uint
+	}{
+		{[]uint{100, 50}, []uint{100, 50}, 8, []uint{100, 50}},
+		{[]uint{0, 10}, []uint{unlimitedDim, 10}, 8, []uint{1024, 10}},
+		{[]uint{5000, 10}, []uint{unlimitedDim, 10}, 8, []uint{5000, 10}},
+		{[]uint{10000, 1000}, []uint{10000, 1000}, 8, []uint{625, 125}},
+		{[]uint{1000000}, []uint{1000000}, 4, []uint{250000}},
+		{[]uint{0}, []uint{0}, 8, []uint{1}},
+		{[]uint{3, 3}, []uint{3, 3}, 0, []uint{3, 3}},
+		{[]uint{2, 2}, []uint{2, 2}, 2 * defaultChunkBytes, []uint{1, 1}},
+	}
+	for i, tc := range tests {
+		if got := autoChunk(tc.dims, tc.maxdims, tc.elemsize); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("case %d: got %v, want %v", i, got, tc.want)
+		}
+	}
+}
+
+func TestCheckChunk(t *testing.T) {
+	tests := []struct {
+		chunk   []uint
+		maxdims []uint
+		ok      bool
+	}{
+		{[]uint{10, 5}, []uint{100, 5}, true},
+		{[]uint{4096, 5}, []uint{unlimitedDim, 5}, true},
+		{[]uint{10}, []uint{100, 5}, false},
+		{[]uint{0, 5}, []uint{100, 5}, false},
+		{[]uint{10, 6}, []uint{100, 5}, false},
+	}
+	for i, tc := range tests {
+		if err := checkChunk(tc.chunk, tc.maxdims); (err == nil) != tc.ok {
+			t.Errorf("case %d (%v in %v): got %v", i, tc.chunk, tc.maxdims, err)
+		}
+	}
+}
+
+func TestDatasetFilters(t *testing.T) {
+	//filter ids defined by HDF5: H5Z_FILTER_DEFLATE, H5Z_FILTER_SHUFFLE and H5Z_FILTER_FLETCHER32
+	const deflate, shuffle, fletcher32 = 1, 2, 3
+	dims := []uint{100, 10}
+	create := func(options HdfCreateOptions) ([]int, error) {
+		dcpl, err := datasetCreatePropList(options, dims, dims, hdf5.T_NATIVE_DOUBLE, reflect.Float64)
+		if err != nil {
+			return nil, err
+		}
+		defer dcpl.Close()
+		return datasetFilters(dcpl.ID())
+	}
+
+	filters, err := create(HdfCreateOptions{Deflate: 6, Shuffle: true, Fletcher32: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{fletcher32, shuffle, deflate}; !reflect.DeepEqual(filters, want) {
+		t.Errorf("got filters %v, want %v", filters, want)
+	}
+	filters, err = create(HdfCreateOptions{Deflate: 0})
+	if err != nil || len(filters) != 0 {
+		t.Errorf("level 0: got filters %v, %v, want none", filters, err)
+	}
+	for _, level := range []int{-1, 10} {
+		if _, err := create(HdfCreateOptions{Deflate: level}); err == nil {
+			t.Errorf("level %d: expected an error", level)
+		}
+	}
+}
+
+func TestWriteCompressed(t *testing.T) {
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = float64(i % 100)
+	}
+	path := filepath.Join(t.TempDir(), "compressed.h5")
+	w, err := NewHdfWriterSync("/values", HdfWriteOptions{
+		Dtype:    reflect.Float64,
+		Dims:     []uint{1000, 100},
+		Create:   HdfCreateOptions{Deflate: 6, Shuffle: true},
+		Filepath: path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.WriteFrom(&values)
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := int64(8 * len(values)); info.Size() >= raw/4 {
+		t.Errorf("file of %d bytes is not compressed, the values are %d bytes", info.Size(), raw)
+	}
+	dset, err := NewHdfDataset("/values", HdfReadOptions{Dtype: reflect.Float64, Filepath: path, ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	if got := *dset.Data.Buffer.(*[]float64); !reflect.DeepEqual(got, values) {
+		t.Error("values read from the compressed dataset do not match")
+	}
+}
@@ -0,0 +1,241 @@
+package hdf5utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DEFAULT_SESSION_TOKEN    = "AWS_SESSION_TOKEN"
+	DEFAULT_PROFILE          = "AWS_PROFILE"
+	DEFAULT_CREDENTIALS_FILE = "AWS_SHARED_CREDENTIALS_FILE"
+	DEFAULT_CONFIG_FILE      = "AWS_CONFIG_FILE"
+)
+
+var ErrNoCredentials = fmt.Errorf("%w: no credentials found", ErrAccessDenied)
+
+// ErrInvalidCredentials is returned for credential sources that are present but unusable,
+// such as a malformed shared file or an access key id without its secret
+var ErrInvalidCredentials = fmt.Errorf("%w: invalid credentials", ErrAccessDenied)
+
+// Credentials used to configure the ROS3 driver
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string //optional, for temporary (STS) credentials
+	Region          string
+	Anonymous       bool //unauthenticated access to public buckets
+}
+
+type CredentialProvider interface {
+	Retrieve() (Credentials, error)
+}
+
+///////////////////////////////////////////////////////
+/////////////////////Env Provider/////////////////////
+
+// EnvCredentialProvider reads the standard AWS environment variables.
+// If Prefix is set, variables are prefixed with it (e.g. PREFIX_AWS_ACCESS_KEY_ID).
+type EnvCredentialProvider struct {
+	Prefix string
+}
+
+func (p EnvCredentialProvider) Retrieve() (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv(keyname(DEFAULT_ACCESS_KEY, p.Prefix)),
+		SecretAccessKey: os.Getenv(keyname(DEFAULT_SECRET_KEY, p.Prefix)),
+		SessionToken:    os.Getenv(keyname(DEFAULT_SESSION_TOKEN, p.Prefix)),
+		Region:          os.Getenv(keyname(DEFAULT_REGION, p.Prefix)),
+	}
+	if err := checkKeys(creds, fmt.Sprintf("environment for prefix '%s'", p.Prefix)); err != nil {
+		return Credentials{}, err
+	}
+	return creds, nil
+}
+
+///////////////////////////////////////////////////////
+/////////////////////Shared File Provider/////////////
+
+// SharedCredentialProvider reads a named profile from the shared AWS credentials and config files.
+// Empty fields default to AWS_PROFILE (or "default"), ~/.aws/credentials and ~/.aws/config
+// honoring AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE.
+type SharedCredentialProvider struct {
+	Profile         string
+	CredentialsFile string
+	ConfigFile      string
+}
+
+func (p SharedCredentialProvider) Retrieve() (Credentials, error) {
+	profile := p.Profile
+	if profile == "" {
+		profile = os.Getenv(DEFAULT_PROFILE)
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	credsFile := firstNonEmpty(p.CredentialsFile, os.Getenv(DEFAULT_CREDENTIALS_FILE), awsHomeFile("credentials"))
+	configFile := firstNonEmpty(p.ConfigFile, os.Getenv(DEFAULT_CONFIG_FILE), awsHomeFile("config"))
+
+	//profiles in the config file other than default are declared as [profile name]
+	configSection := profile
+	if profile != "default" {
+		configSection = "profile " + profile
+	}
+
+	values := map[string]string{}
+	for _, src := range []struct {
+		path    string
+		section string
+	}{{configFile, configSection}, {credsFile, profile}} {
+		if src.path == "" {
+			continue
+		}
+		section, err := readIniSection(src.path, src.section)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return Credentials{}, err
+		}
+		//the credentials file takes precedence over the config file
+		for k, v := range section {
+			values[k] = v
+		}
+	}
+
+	creds := Credentials{
+		AccessKeyID:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+		Region:          values["region"],
+	}
+	if err := checkKeys(creds, fmt.Sprintf("shared aws files for profile '%s'", profile)); err != nil {
+		return Credentials{}, err
+	}
+	return creds, nil
+}
+
+// checkKeys reports a source without keys as ErrNoCredentials and a source setting only
+// one of the key pair as ErrInvalidCredentials
+func checkKeys(creds Credentials, source string) error {
+	switch {
+	case creds.AccessKeyID == "" && creds.SecretAccessKey == "":
+		return fmt.Errorf("%w in %s", ErrNoCredentials, source)
+	case creds.AccessKeyID == "" || creds.SecretAccessKey == "":
+		return fmt.Errorf("%w: %s sets only one of the access key id and secret access key", ErrInvalidCredentials, source)
+	}
+	return nil
+}
+
+func awsHomeFile(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// readIniSection returns the lower-cased key/value pairs for a section of an aws style ini file
+func readIniSection(path string, section string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == section
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("%w: line %d of %s is not a section or key = value pair", ErrInvalidCredentials, n, path)
+		}
+		if !inSection {
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+	}
+	return values, scanner.Err()
+}
+
+///////////////////////////////////////////////////////
+/////////////////////Static and Anonymous/////////////
+
+// StaticCredentialProvider returns a fixed set of credentials, including temporary
+// session credentials obtained from STS
+type StaticCredentialProvider struct {
+	Credentials Credentials
+}
+
+func (p StaticCredentialProvider) Retrieve() (Credentials, error) {
+	if !p.Credentials.Anonymous {
+		if err := checkKeys(p.Credentials, "static credentials"); err != nil {
+			return Credentials{}, err
+		}
+	}
+	return p.Credentials, nil
+}
+
+// AnonymousCredentialProvider disables request signing for public buckets
+type AnonymousCredentialProvider struct {
+	Region string
+}
+
+func (p AnonymousCredentialProvider) Retrieve() (Credentials, error) {
+	return Credentials{Anonymous: true, Region: p.Region}, nil
+}
+
+///////////////////////////////////////////////////////
+/////////////////////Provider Chain///////////////////
+
+// ChainCredentialProvider returns the credentials of the first provider that succeeds.
+// Providers that find no credentials are skipped, any other error is returned so a broken
+// source is not silently replaced by a later provider or anonymous access.
+type ChainCredentialProvider []CredentialProvider
+
+func (c ChainCredentialProvider) Retrieve() (Credentials, error) {
+	errs := []string{}
+	for _, p := range c {
+		creds, err := p.Retrieve()
+		if err == nil {
+			return creds, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return Credentials{}, err
+		}
+		errs = append(errs, err.Error())
+	}
+	return Credentials{}, fmt.Errorf("%w: %s", ErrNoCredentials, strings.Join(errs, "; "))
+}
+
+// DefaultCredentialChain checks the (optionally prefixed) environment followed by
+// the named profile of the shared aws credential files
+func DefaultCredentialChain(envPrefix string, profile string) CredentialProvider {
+	return ChainCredentialProvider{
+		EnvCredentialProvider{Prefix: envPrefix},
+		SharedCredentialProvider{Profile: profile},
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
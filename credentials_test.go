@@ -0,0 +1,168 @@
+package hdf5utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvCredentialProvider(t *testing.T) {
+	t.Setenv("TEST_AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("TEST_AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("TEST_AWS_SESSION_TOKEN", "TOKEN")
+	t.Setenv("TEST_AWS_DEFAULT_REGION", "us-west-2")
+
+	creds, err := EnvCredentialProvider{Prefix: "TEST"}.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN", Region: "us-west-2"}
+	if creds != want {
+		t.Errorf("got %+v, want %+v", creds, want)
+	}
+
+	_, err = EnvCredentialProvider{Prefix: "MISSING"}.Retrieve()
+	if !errors.Is(err, ErrNoCredentials) || !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+
+	t.Setenv("HALF_AWS_ACCESS_KEY_ID", "AKID")
+	_, err = EnvCredentialProvider{Prefix: "HALF"}.Retrieve()
+	if !errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("access key id without a secret: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestSharedCredentialProvider(t *testing.T) {
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	writeFile(t, credsFile, `
+[default]
+aws_access_key_id = DEFAULTKEY
+aws_secret_access_key = DEFAULTSECRET
+
+[dev]
+# comment
+aws_access_key_id = DEVKEY
+AWS_Secret_Access_Key = DEVSECRET
+aws_session_token = DEVTOKEN
+`)
+	writeFile(t, configFile, `
+[default]
+region = us-east-2
+
+[profile dev]
+region = eu-west-1
+aws_access_key_id = CONFIGKEY
+`)
+
+	tests := []struct {
+		profile string
+		want    Credentials
+	}{
+		{"", Credentials{AccessKeyID: "DEFAULTKEY", SecretAccessKey: "DEFAULTSECRET", Region: "us-east-2"}},
+		{"dev", Credentials{AccessKeyID: "DEVKEY", SecretAccessKey: "DEVSECRET", SessionToken: "DEVTOKEN", Region: "eu-west-1"}},
+	}
+	t.Setenv(DEFAULT_PROFILE, "")
+	for _, tc := range tests {
+		p := SharedCredentialProvider{Profile: tc.profile, CredentialsFile: credsFile, ConfigFile: configFile}
+		creds, err := p.Retrieve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds != tc.want {
+			t.Errorf("profile '%s': got %+v, want %+v", tc.profile, creds, tc.want)
+		}
+	}
+
+	p := SharedCredentialProvider{Profile: "missing", CredentialsFile: credsFile, ConfigFile: filepath.Join(dir, "none")}
+	if _, err := p.Retrieve(); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestChainCredentialProvider(t *testing.T) {
+	static := StaticCredentialProvider{Credentials{AccessKeyID: "KEY", SecretAccessKey: "SECRET"}}
+	chain := ChainCredentialProvider{StaticCredentialProvider{}, static}
+	creds, err := chain.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "KEY" {
+		t.Errorf("expected the second provider's credentials, got %+v", creds)
+	}
+
+	_, err = ChainCredentialProvider{StaticCredentialProvider{}}.Retrieve()
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+
+	creds, err = AnonymousCredentialProvider{Region: "us-west-2"}.Retrieve()
+	if err != nil || !creds.Anonymous || creds.Region != "us-west-2" {
+		t.Errorf("unexpected anonymous credentials %+v, %v", creds, err)
+	}
+}
+
+func TestCorruptSharedCredentials(t *testing.T) {
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	writeFile(t, credsFile, "[default]\naws_access_key_id = KEY\naws_secret_access_key\n")
+	t.Setenv(DEFAULT_CREDENTIALS_FILE, credsFile)
+	t.Setenv(DEFAULT_CONFIG_FILE, filepath.Join(dir, "none"))
+	t.Setenv(DEFAULT_PROFILE, "")
+	t.Setenv(DEFAULT_ACCESS_KEY, "")
+	t.Setenv(DEFAULT_SECRET_KEY, "")
+
+	if _, err := (SharedCredentialProvider{}).Retrieve(); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("malformed line: got %v, want ErrInvalidCredentials", err)
+	}
+	//the chain stops at the broken file instead of falling through to anonymous access
+	chain := ChainCredentialProvider{SharedCredentialProvider{}, AnonymousCredentialProvider{}}
+	if creds, err := chain.Retrieve(); !errors.Is(err, ErrInvalidCredentials) || creds.Anonymous {
+		t.Errorf("chain: got %+v, %v, want ErrInvalidCredentials", creds, err)
+	}
+	if _, creds, _, err := resolveRemote("s3://bucket/key.h5", HdfFileOptions{}); !errors.Is(err, ErrInvalidCredentials) || creds.Anonymous {
+		t.Errorf("resolve: got %+v, %v, want ErrInvalidCredentials", creds, err)
+	}
+
+	//an unreadable file is reported rather than skipped
+	if _, err := (SharedCredentialProvider{CredentialsFile: dir}).Retrieve(); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("directory as the credentials file: got %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveRemoteCredentials(t *testing.T) {
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "credentials")
+	writeFile(t, credsFile, "[batch]\naws_access_key_id = FILEKEY\naws_secret_access_key = FILESECRET\n")
+	t.Setenv(DEFAULT_CREDENTIALS_FILE, credsFile)
+	t.Setenv(DEFAULT_CONFIG_FILE, filepath.Join(dir, "none"))
+	t.Setenv(DEFAULT_ACCESS_KEY, "")
+	t.Setenv(DEFAULT_SECRET_KEY, "")
+	t.Setenv("JOB_AWS_DEFAULT_REGION", "us-west-2")
+	url := "s3://bucket/key.h5"
+
+	//the env prefix and the shared file profile are independent
+	_, creds, region, err := resolveRemote(url, HdfFileOptions{EnvPrefix: "JOB", Profile: "batch"})
+	if err != nil || creds.AccessKeyID != "FILEKEY" || region != "us-west-2" {
+		t.Errorf("got %+v in %s, %v", creds, region, err)
+	}
+
+	_, creds, _, err = resolveRemote(url, HdfFileOptions{Profile: "missing"})
+	if err != nil || !creds.Anonymous {
+		t.Errorf("the default chain must fall back to anonymous access, got %+v, %v", creds, err)
+	}
+	_, _, _, err = resolveRemote(url, HdfFileOptions{Credentials: StaticCredentialProvider{}})
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("explicit providers must not fall back, got %v", err)
+	}
+}
@@ -1,14 +1,18 @@
 package hdf5utils
 
 import (
+	"bytes"
+	"context"
+	"encoding/gob"
 	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	hdf5 "github.com/usace/go-hdf5"
 )
@@ -17,45 +21,214 @@ const (
 	DEFAULT_ACCESS_KEY = "AWS_ACCESS_KEY_ID"
 	DEFAULT_SECRET_KEY = "AWS_SECRET_ACCESS_KEY"
 	DEFAULT_REGION     = "AWS_DEFAULT_REGION"
+	DEFAULT_ENDPOINT   = "AWS_S3_ENDPOINT"
+	DEFAULT_PATH_STYLE = "AWS_S3_FORCE_PATH_STYLE"
 )
 
+// HdfFileOptions controls how OpenFileWithOptions locates and authenticates remote files
+type HdfFileOptions struct {
+	EnvPrefix   string             //prefix of the credential and endpoint env vars, e.g. PREFIX_AWS_ACCESS_KEY_ID
+	Profile     string             //profile name in the shared aws files, defaults to AWS_PROFILE or default
+	Credentials CredentialProvider //defaults to DefaultCredentialChain(EnvPrefix, Profile), falling back to anonymous access
+	S3Endpoint  string             //custom s3 endpoint, defaults to the AWS_S3_ENDPOINT env var
+	S3PathStyle bool               //force path-style s3 urls, also set with AWS_S3_FORCE_PATH_STYLE
+	Backend     HdfBackend         //remote access method, see BackendAuto for the default selection
+	HttpAuth    HttpAuth           //authentication for the http download backend
+	HttpClient  *http.Client       //client for the http download backend, defaults to http.DefaultClient
+	Cache       *FileCache         //optional local cache for remote files
+	SWMR        bool               //open local files for single-writer/multiple-reader access to follow a running writer
+	Retry       *RetryPolicy       //retry transient remote open and read failures, nil disables retries
+}
+
 func OpenFile(url ...string) (*hdf5.File, error) {
 	switch len(url) {
 	case 1:
-		return openFileWithPrefix(url[0], "")
+		return OpenFileWithOptions(url[0], HdfFileOptions{})
 	case 2:
-		return openFileWithPrefix(url[0], url[1])
+		return OpenFileWithOptions(url[0], HdfFileOptions{EnvPrefix: url[1]})
 	default:
 		return nil, errors.New("invalid HDF url")
 	}
 }
 
-func openFileWithPrefix(url string, profile string) (*hdf5.File, error) {
-	var f *hdf5.File
-	var err error
+func OpenFileWithOptions(url string, options HdfFileOptions) (*hdf5.File, error) {
+	return openFile(context.Background(), url, options)
+}
 
-	if strings.HasPrefix(url, "https") {
+// openFile opens a file, retrying remote opens until ctx is done
+func openFile(ctx context.Context, url string, options HdfFileOptions) (*hdf5.File, error) {
+	if isRemoteUrl(url) {
+		var f *hdf5.File
+		err := options.Retry.do(ctx, func() (err error) {
+			f, err = openRemote(url, options)
+			return err
+		}, nil)
+		return f, err
+	}
+	flags := hdf5.F_ACC_RDONLY
+	if options.SWMR {
+		if swmrReadFlag == 0 {
+			return nil, newHdfError("open file", url, nil, errNoSWMR)
+		}
+		flags |= swmrReadFlag
+	}
+	return openLocalFile(url, flags)
+}
 
-		fapl_id, _ := hdf5.NewPropList(hdf5.P_FILE_ACCESS)
-		region := os.Getenv(keyname(DEFAULT_REGION, profile))
+func openRemote(url string, options HdfFileOptions) (*hdf5.File, error) {
+	if options.Cache != nil {
+		return options.Cache.open(url, options)
+	}
+	if useHttpBackend(url, options) {
+		return openHttpDownload(url, options)
+	}
+	return openRemoteFile(url, options)
+}
+
+type HdfCreateMode int
 
-		ros3_fa := hdf5.H5FD_ROS3_FAPL{
-			Version:               1,
-			Authenticate:          true,
-			AWS_REGION:            region,
-			AWS_ACCESS_KEY_ID:     os.Getenv(keyname(DEFAULT_ACCESS_KEY, profile)),
-			AWS_SECRET_ACCESS_KEY: os.Getenv(keyname(DEFAULT_SECRET_KEY, profile)),
+const (
+	CreateExclusive HdfCreateMode = iota //fail if the file already exists
+	CreateTruncate                       //erase the contents of an existing file
+)
+
+// CreateFile creates a new local HDF5 file opened for read-write access
+func CreateFile(path string, mode HdfCreateMode) (*hdf5.File, error) {
+	if isRemoteUrl(path) {
+		return nil, newHdfError("create file", path, ErrRemoteWrite, nil)
+	}
+	var flags int
+	switch mode {
+	case CreateExclusive:
+		if _, err := os.Stat(path); err == nil {
+			return nil, newHdfError("create file", path, nil, os.ErrExist)
 		}
+		flags = hdf5.F_ACC_EXCL
+	case CreateTruncate:
+		flags = hdf5.F_ACC_TRUNC
+	default:
+		return nil, fmt.Errorf("invalid create mode: %d", mode)
+	}
+	f, err := hdf5.CreateFile(path, flags)
+	if err != nil {
+		return nil, newHdfError("create file", path, nil, err)
+	}
+	return f, nil
+}
+
+// OpenFileRW opens an existing local HDF5 file for read-write access
+func OpenFileRW(path string) (*hdf5.File, error) {
+	if isRemoteUrl(path) {
+		return nil, newHdfError("open file", path, ErrRemoteWrite, nil)
+	}
+	return openLocalFile(path, hdf5.F_ACC_RDWR)
+}
+
+func isRemoteUrl(url string) bool {
+	return isS3Url(url) || isHttpUrl(url)
+}
+
+func openLocalFile(path string, flags int) (*hdf5.File, error) {
+	if _, err := os.Stat(path); err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return nil, newHdfError("open file", path, ErrFileNotFound, err)
+		case errors.Is(err, os.ErrPermission):
+			return nil, newHdfError("open file", path, ErrAccessDenied, err)
+		default:
+			return nil, newHdfError("open file", path, nil, err)
+		}
+	}
+	f, err := hdf5.OpenFile(path, flags)
+	if err != nil {
+		return nil, newHdfError("open file", path, nil, err)
+	}
+	return f, nil
+}
+
+func openRemoteFile(url string, options HdfFileOptions) (*hdf5.File, error) {
+	url, creds, region, err := resolveRemote(url, options)
+	if err != nil {
+		return nil, err
+	}
 
-		hdf5.H5PsetFaplRos3d(fapl_id, ros3_fa)
-		f, err = hdf5.OpenFileWithProp(url, hdf5.F_ACC_RDONLY, fapl_id)
+	fapl_id, err := hdf5.NewPropList(hdf5.P_FILE_ACCESS)
+	if err != nil {
+		return nil, err
+	}
+	defer fapl_id.Close()
+
+	ros3_fa := hdf5.H5FD_ROS3_FAPL{
+		Version:      1,
+		Authenticate: !creds.Anonymous,
+		AWS_REGION:   region,
+	}
+	if !creds.Anonymous {
+		ros3_fa.AWS_ACCESS_KEY_ID = creds.AccessKeyID
+		ros3_fa.AWS_SECRET_ACCESS_KEY = creds.SecretAccessKey
+	}
+
+	hdf5.H5PsetFaplRos3d(fapl_id, ros3_fa)
+	if !creds.Anonymous && creds.SessionToken != "" {
+		err = setFaplRos3Token(fapl_id.ID(), creds.SessionToken)
 		if err != nil {
-			log.Printf("Failed opening %s at %s", url, region)
+			return nil, err
 		}
-	} else {
-		f, err = hdf5.OpenFile(url, hdf5.F_ACC_RDONLY)
 	}
-	return f, err
+	f, err := hdf5.OpenFileWithProp(url, hdf5.F_ACC_RDONLY, fapl_id)
+	if err != nil {
+		kind := remoteOpenKind(url, creds, region, options.HttpClient)
+		return nil, newHdfError("open file", url, kind, fmt.Errorf("region %s: %w", region, err))
+	}
+	return f, nil
+}
+
+// remoteOpenKind asks the server why a ROS3 open failed, since the HDF5 error stack does not
+// report the http status.  A HEAD request signed like the ROS3 requests classifies missing
+// objects as ErrFileNotFound and rejected credentials as ErrAccessDenied, so they are not
+// retried.  Other failures are ErrRemoteAccess.
+func remoteOpenKind(url string, creds Credentials, region string, client *http.Client) error {
+	_, err := newS3ReaderAt(url, creds, region, client)
+	switch kind := errorKind(err); kind {
+	case ErrFileNotFound, ErrAccessDenied:
+		return kind
+	}
+	return ErrRemoteAccess
+}
+
+// resolveRemote retrieves credentials and converts s3:// urls to the http(s) url of the object.
+// When no credentials provider is set and the default chain finds no credentials the file
+// is opened anonymously, as public buckets are.  Providers set in the options must succeed.
+func resolveRemote(url string, options HdfFileOptions) (string, Credentials, string, error) {
+	provider := options.Credentials
+	if provider == nil {
+		provider = DefaultCredentialChain(options.EnvPrefix, options.Profile)
+	}
+	creds, err := provider.Retrieve()
+	if err != nil && options.Credentials == nil && errors.Is(err, ErrNoCredentials) {
+		logger().Debug("no credentials found, opening anonymously", "file", url)
+		creds, err = Credentials{Anonymous: true}, nil
+	}
+	if err != nil {
+		return "", Credentials{}, "", newHdfError("open file", url, ErrAccessDenied, err)
+	}
+
+	region := firstNonEmpty(creds.Region, os.Getenv(keyname(DEFAULT_REGION, options.EnvPrefix)))
+	if isS3Url(url) {
+		if region == "" {
+			region = DEFAULT_S3_REGION
+		}
+		endpoint := firstNonEmpty(options.S3Endpoint, os.Getenv(keyname(DEFAULT_ENDPOINT, options.EnvPrefix)))
+		pathStyle := options.S3PathStyle
+		if !pathStyle {
+			pathStyle, _ = strconv.ParseBool(os.Getenv(keyname(DEFAULT_PATH_STYLE, options.EnvPrefix)))
+		}
+		url, err = ResolveS3Url(url, region, endpoint, pathStyle)
+		if err != nil {
+			return "", Credentials{}, "", err
+		}
+	}
+	return url, creds, region, nil
 }
 
 func keyname(keyname string, store string) string {
@@ -70,12 +243,13 @@ func NewHdfStrSet(sizes ...int) HdfStrSet {
 	for _, v := range sizes {
 		result += v
 	}
-	return HdfStrSet{sizes, result}
+	return HdfStrSet{sizes, result, nil}
 }
 
 type HdfStrSet struct {
 	sizes      []int
 	buffersize int
+	formats    []StringFormat //padding and character set of each column, detected from the file
 }
 
 func (h HdfStrSet) ToString() string {
@@ -94,9 +268,12 @@ func (h *HdfStrSet) FromString(s string) error {
 		if err != nil {
 			return err
 		}
+		if size <= 0 {
+			return fmt.Errorf("invalid string width %d in %q", size, s)
+		}
 		sizes[i] = size
 	}
-	h.sizes = sizes
+	*h = NewHdfStrSet(sizes...)
 	return nil
 }
 
@@ -114,7 +291,7 @@ func (h HdfStrSet) BytesTo(col int) (int, error) {
 	if col > len(h.sizes)-1 {
 		return -1, errors.New("Column number requested exceeds the size of the string array")
 	}
-	for i := 0; i < col-1; i++ {
+	for i := 0; i < col; i++ {
 		result += h.sizes[i]
 	}
 	return result, nil
@@ -127,6 +304,48 @@ func (h HdfStrSet) Size(col int) (int, error) {
 	return h.sizes[col], nil
 }
 
+// Format returns the padding and character set of a column, null terminated ASCII when unknown
+func (h HdfStrSet) Format(col int) StringFormat {
+	if col < len(h.formats) {
+		return h.formats[col]
+	}
+	return StringFormat{}
+}
+
+// decode removes the padding of a column value.  Columns without a detected format, such as
+// string sizes supplied by the caller to an HdfStrSet built outside a reader, have surrounding whitespace trimmed.
+func (h HdfStrSet) decode(col int, b []byte, raw bool) string {
+	if raw {
+		return string(b)
+	}
+	if col >= len(h.formats) {
+		return strings.TrimSpace(string(b))
+	}
+	return h.formats[col].Decode(b)
+}
+
+// gobStrSet holds the sizes and formats of an HdfStrSet sent through the gob encoded async pipe
+type gobStrSet struct {
+	Sizes   []int
+	Formats []StringFormat
+}
+
+func (h HdfStrSet) GobEncode() ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(gobStrSet{h.sizes, h.formats})
+	return b.Bytes(), err
+}
+
+func (h *HdfStrSet) GobDecode(b []byte) error {
+	var g gobStrSet
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&g); err != nil {
+		return err
+	}
+	*h = NewHdfStrSet(g.Sizes...)
+	h.formats = g.Formats
+	return nil
+}
+
 ///////////////////////////////////////////////////////
 ///////////HDF Interfaces///////////////
 
@@ -139,14 +358,38 @@ type HdfReader interface {
 	Close()
 }
 
+// Refresher is implemented by readers that can re-read the extent of extendable datasets
+type Refresher interface {
+	Refresh() error
+}
+
+// StrSizer is implemented by readers that detect the column sizes of string datasets
+type StrSizer interface {
+	StrSizes() HdfStrSet
+}
+
+// Dtyper is implemented by readers that infer the read kind from the dataset datatype
+type Dtyper interface {
+	Dtype() reflect.Kind
+}
+
+// Enumer is implemented by readers that return the name/value mapping of enum datasets
+type Enumer interface {
+	Enum() (*HdfEnum, error)
+}
+
 type HdfReadOptions struct {
-	Dtype              reflect.Kind
+	Dtype              reflect.Kind //kind of the read buffers, inferred from the dataset when unset
 	Strsizes           HdfStrSet
 	IncrementalRead    bool
 	IncrementalReadDir int //0 by row, 1 by column
 	IncrementSize      int
 	ReadOnCreate       bool //reads data in when the new datraset is created
+	RawStrings         bool //return fixed length strings with their padding instead of decoding them
+	AllowLossy         bool //allow numeric conversions that may lose values, such as float64 to float32
 	Filepath           string
+	FileOptions        HdfFileOptions //used when opening Filepath
+	Pool               *FilePool      //shares Filepath handles between readers, defaults to DefaultFilePool
 	File               *hdf5.File
 	//Async              bool
 }
@@ -155,6 +398,7 @@ type HdfData struct {
 	DsetDims []uint //dimension of the full dataset
 	Dims     []uint //dimension of the extracted dataset
 	Buffer   interface{}
+	Strsizes HdfStrSet //column widths and formats of fixed length string buffers
 }
 
 /////////////////////HDF Dataset//////////////////////
@@ -182,11 +426,18 @@ func NewHdfDataset(datapath string, options HdfReadOptions) (*HdfDataset, error)
 	if err != nil {
 		return nil, err
 	}
+	if sr, ok := reader.(StrSizer); ok {
+		options.Strsizes = sr.StrSizes() //row and column reads of string tables use the detected sizes
+	}
+	if dr, ok := reader.(Dtyper); ok {
+		options.Dtype = dr.Dtype()
+	}
 
 	if options.ReadOnCreate {
 		if !options.IncrementalRead {
 			data, err = reader.Read()
 			if err != nil {
+				reader.Close()
 				return nil, err
 			}
 		}
@@ -213,6 +464,72 @@ func (h *HdfDataset) Dims() []uint {
 	return h.dsetdims
 }
 
+// Dtype returns the kind the dataset is read as, inferred from the file when the read options leave it unset
+func (h *HdfDataset) Dtype() reflect.Kind {
+	return h.options.Dtype
+}
+
+// Enum returns the name/value mapping of enum datasets.  Enum datasets are read as their
+// integer values by default and as member names when Dtype is reflect.String.
+func (h *HdfDataset) Enum() (*HdfEnum, error) {
+	er, ok := h.Reader.(Enumer)
+	if !ok {
+		return nil, fmt.Errorf("the dataset reader does not support enums: %w", ErrUnsupportedOperation)
+	}
+	return er.Enum()
+}
+
+// Refresh re-reads the dataset dimensions so rows appended since the dataset was opened are visible
+func (h *HdfDataset) Refresh() error {
+	rr, ok := h.Reader.(Refresher)
+	if !ok {
+		return fmt.Errorf("the dataset reader does not support refresh: %w", ErrUnsupportedOperation)
+	}
+	err := rr.Refresh()
+	if err != nil {
+		return err
+	}
+	h.dsetdims = h.Reader.Dims()
+	return nil
+}
+
+// Watch polls the dataset every interval, calling onChange with the new dimensions whenever
+// the extent changes.  Watch blocks until stop is closed, onChange returns false or a refresh fails.
+func (h *HdfDataset) Watch(interval time.Duration, stop <-chan struct{}, onChange func(dims []uint) bool) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := append([]uint{}, h.dsetdims...)
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			err := h.Refresh()
+			if err != nil {
+				return err
+			}
+			if !equalDims(last, h.dsetdims) {
+				last = append([]uint{}, h.dsetdims...)
+				if !onChange(last) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func equalDims(a []uint, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (h *HdfDataset) Read() error {
 	data, err := h.Reader.Read()
 	if err != nil {
@@ -293,7 +610,11 @@ func (h *HdfDataset) ReadColumn(c int, dest interface{}) error {
 		}
 		return h.readIncrementColumn(c, dest)
 	} else {
-		return h.readVector(false, c, dest)
+		if h.options.Dtype == reflect.String {
+			return h.readVectorString(false, c, dest)
+		} else {
+			return h.readVector(false, c, dest)
+		}
 	}
 }
 
@@ -366,12 +687,16 @@ func (h *HdfDataset) readVectorString(rowread bool, index int, dest interface{})
 		}
 	}()
 
+	if _, ok := h.Data.Buffer.(*[]string); ok {
+		return h.readVector(rowread, index, dest) //variable length strings
+	}
+
 	buffer := *h.Data.Buffer.(*[]uint8)
 	value := reflect.Indirect(reflect.ValueOf(dest))
 	if rowread {
 		offset := index * h.options.Strsizes.RowSize()
-		for _, v := range h.options.Strsizes.Cols() {
-			value.Set(reflect.Append(value, reflect.ValueOf(strings.TrimSpace(string(buffer[offset:offset+v]))))) //@TODO might need to revisit this and strip null values (\x00)
+		for c, v := range h.options.Strsizes.Cols() {
+			value.Set(reflect.Append(value, reflect.ValueOf(h.options.Strsizes.decode(c, buffer[offset:offset+v], h.options.RawStrings))))
 			offset += v
 		}
 	} else {
@@ -380,7 +705,7 @@ func (h *HdfDataset) readVectorString(rowread bool, index int, dest interface{})
 			bt, _ := h.options.Strsizes.BytesTo(index)
 			sz, _ := h.options.Strsizes.Size(index)
 			offset := i*h.options.Strsizes.RowSize() + bt
-			value.Set(reflect.Append(value, reflect.ValueOf(strings.TrimSpace(string(buffer[offset:offset+sz])))))
+			value.Set(reflect.Append(value, reflect.ValueOf(h.options.Strsizes.decode(index, buffer[offset:offset+sz], h.options.RawStrings))))
 		}
 	}
 	return nil
@@ -395,23 +720,39 @@ type HdfReaderAsync struct {
 	options  HdfReadOptions
 	dims     []uint
 	subset   string
+	strsizes HdfStrSet //string sizes and formats detected by the child process
 }
 
 func (h *HdfReaderAsync) Close() {
 
 }
 
+// StrSizes returns the column byte widths and formats of string datasets from the last read
+func (h *HdfReaderAsync) StrSizes() HdfStrSet {
+	return h.strsizes
+}
+
 func (h *HdfReaderAsync) Dims() []uint {
 	if h.options.IncrementalRead {
 		data, err := h.read(true)
 		if err != nil {
-			log.Println(err)
+			logger().Error("unable to read dimensions", "file", h.filepath, "datapath", h.datapath, "error", err)
+			return h.dims
 		}
 		h.dims = data.Dims
 	}
 	return h.dims
 }
 
+func (h *HdfReaderAsync) Refresh() error {
+	data, err := h.read(true)
+	if err != nil {
+		return err
+	}
+	h.dims = data.Dims
+	return nil
+}
+
 func (h *HdfReaderAsync) Read() (*HdfData, error) {
 	data, err := h.read(false)
 	if err != nil {
@@ -426,7 +767,7 @@ func (h *HdfReaderAsync) ReadInto(dest interface{}) error {
 }
 
 func (h *HdfReaderAsync) ReadSubset(rowrange []int, colrange []int) (*HdfData, error) {
-	h.subset = fmt.Sprintf("%d,%d,%d,%d", rowrange[0], rowrange[1], colrange[0], colrange[1])
+	h.subset = subsetCsv(rowrange, colrange)
 	data, err := h.read(false)
 	if err != nil {
 		return nil, err
@@ -450,7 +791,19 @@ func (h *HdfReaderAsync) read(dimsOnly bool) (*HdfData, error) {
 	}
 	pipes = append(pipes, pipe)
 	wg.Add(1)
-	ai := AsyncInput{
+	var data HdfData
+	go RunAsync("DSET", h.asyncInput(pipe, dimsOnly), &data, &wg) //@TODO what if async command return error????
+
+	wg.Wait()
+	ClosePipes(pipes)
+	if len(data.Strsizes.Cols()) > 0 {
+		h.strsizes = data.Strsizes
+	}
+	return &data, nil
+}
+
+func (h *HdfReaderAsync) asyncInput(pipe string, dimsOnly bool) AsyncInput {
+	return AsyncInput{
 		Filepath:  h.filepath,
 		Datapath:  h.datapath,
 		Namedpipe: pipe,
@@ -461,12 +814,10 @@ func (h *HdfReaderAsync) read(dimsOnly bool) (*HdfData, error) {
 			"HDFDIMSONLY":    strconv.FormatBool(dimsOnly),
 		},
 	}
-	var data HdfData
-	go RunAsync("DSET", ai, &data, &wg) //@TODO what if async command return error????
+}
 
-	wg.Wait()
-	ClosePipes(pipes)
-	return &data, nil
+func subsetCsv(rowrange []int, colrange []int) string {
+	return fmt.Sprintf("%d,%d,%d,%d", rowrange[0], rowrange[1], colrange[0], colrange[1])
 }
 
 ///////////////////////////////////////////////////////
@@ -476,56 +827,141 @@ type HdfReaderSync struct {
 	file       *hdf5.File
 	dset       *hdf5.Dataset
 	dtype      reflect.Kind
+	memtype    *hdf5.Datatype //memory datatype of numeric kinds, HDF5 converts the file elements to it
+	allowLossy bool
 	dims       []uint //dimension of the source dataset
+	maxdims    []uint //maximum dimension of the source dataset, H5S_UNLIMITED for extendable dimensions
 	strsizes   HdfStrSet
-	fileCloser bool
+	varlen     bool      //variable length strings, read into []string buffers
+	enum       *HdfEnum  //enum datasets read as strings, read into []string buffers of member names
+	pool       *FilePool //pool the file was acquired from, nil when the caller owns the file
+	busy       sync.Mutex
+
+	//used to reopen remote files after transient read failures
+	filepath    string
+	datapath    string
+	fileOptions HdfFileOptions
 }
 
 func NewHdfReaderSync(datapath string, options HdfReadOptions) (HdfReader, error) {
 	var err error
-	fileCloser := false
+	var pool *FilePool
 	f := options.File
 	if f == nil {
-		f, err = OpenFile(options.Filepath)
+		pool = options.Pool
+		if pool == nil {
+			pool = DefaultFilePool
+		}
+		f, err = pool.Acquire(options.Filepath, options.FileOptions)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
-		fileCloser = true
 	}
 
-	//a := f.LinkExists(datapath)
-	//fmt.Println(a)
-	//if !f.LinkExists(datapath) {
-	//	return nil, errors.New(fmt.Sprintf("Data path: '%s' not found in dataset\n", datapath))
-	//}
-
-	dset, err := f.OpenDataset(datapath)
+	dset, err := openDataset(f, datapath)
 	if err != nil {
+		if pool != nil {
+			pool.Release(f)
+		}
 		return nil, err
 	}
-	dset.Datatype()
 	space := dset.Space()
 	defer space.Close()
 	dims, max, err := space.SimpleExtentDims()
-	log.Printf("Dataset Diminsions are %v, %v\n", dims, max)
 	if err != nil {
+		dset.Close()
+		if pool != nil {
+			pool.Release(f)
+		}
+		return nil, newHdfError("read dimensions", datapath, nil, err)
+	}
+
+	if options.Dtype == reflect.Invalid {
+		options.Dtype, err = inferKind(dset)
+		if err != nil {
+			dset.Close()
+			if pool != nil {
+				pool.Release(f)
+			}
+			return nil, err
+		}
+	}
+
+	memtype, err := readMemDatatype(dset, options.Dtype, options.AllowLossy)
+	if err != nil {
+		dset.Close()
+		if pool != nil {
+			pool.Release(f)
+		}
 		return nil, err
 	}
 
+	strsizes := options.Strsizes
+	varlen := false
+	var enum *HdfEnum
+	if options.Dtype == reflect.String {
+		enum, err = datasetEnum(dset)
+		if err == nil && enum == nil {
+			varlen, err = datasetIsVarLenString(dset)
+		}
+		if err == nil && enum != nil && len(strsizes.Cols()) > 0 {
+			err = newHdfError("read string sizes", datapath, ErrDatatypeMismatch, errors.New("string sizes were supplied for an enum dataset"))
+		} else if err == nil && varlen && len(strsizes.Cols()) > 0 {
+			err = newHdfError("read string sizes", datapath, ErrDatatypeMismatch, errors.New("string sizes were supplied for a variable length string dataset"))
+		} else if err == nil && !varlen && enum == nil {
+			strsizes, err = resolveStrSizes(dset, dims, options.Strsizes)
+		}
+		if err != nil {
+			dset.Close()
+			if pool != nil {
+				pool.Release(f)
+			}
+			return nil, err
+		}
+	}
+
+	logger().Debug("opened dataset", "file", options.Filepath, "datapath", datapath, "dims", dims, "maxdims", max)
 	return &HdfReaderSync{
-		file:       f,
-		dset:       dset,
-		dims:       dims,
-		dtype:      options.Dtype,
-		strsizes:   options.Strsizes,
-		fileCloser: fileCloser,
+		file:        f,
+		dset:        dset,
+		dims:        dims,
+		maxdims:     max,
+		dtype:       options.Dtype,
+		memtype:     memtype,
+		allowLossy:  options.AllowLossy,
+		strsizes:    strsizes,
+		varlen:      varlen,
+		enum:        enum,
+		pool:        pool,
+		filepath:    options.Filepath,
+		datapath:    datapath,
+		fileOptions: options.FileOptions,
 	}, nil
 }
 
+// openDataset opens a dataset, reporting ErrDatasetNotFound when the path does not exist in the file
+func openDataset(f *hdf5.File, datapath string) (*hdf5.Dataset, error) {
+	dset, err := f.OpenDataset(datapath)
+	if err != nil {
+		if !f.LinkExists(datapath) {
+			return nil, newHdfError("open dataset", datapath, ErrDatasetNotFound, err)
+		}
+		return nil, newHdfError("open dataset", datapath, nil, err)
+	}
+	return dset, nil
+}
+
+// Close waits for any read still running, including reads abandoned by a cancelled
+// context, before closing the dataset
 func (h *HdfReaderSync) Close() {
+	h.busy.Lock()
+	defer h.busy.Unlock()
 	defer h.dset.Close()
-	if h.fileCloser {
-		defer h.file.Close()
+	if h.memtype != nil {
+		defer h.memtype.Close()
+	}
+	if h.pool != nil {
+		defer h.pool.Release(h.file)
 	}
 }
 
@@ -533,16 +969,87 @@ func (h *HdfReaderSync) Dims() []uint {
 	return h.dims
 }
 
+func (h *HdfReaderSync) MaxDims() []uint {
+	return h.maxdims
+}
+
+// Dtype returns the kind the dataset is read as, inferred from the file when not set in the read options
+func (h *HdfReaderSync) Dtype() reflect.Kind {
+	return h.dtype
+}
+
+// StrSizes returns the column byte widths of string datasets, detected from the file
+// when they were not supplied in the read options
+func (h *HdfReaderSync) StrSizes() HdfStrSet {
+	return h.strsizes
+}
+
+// Refresh re-queries the dataspace to pick up changes to the extent of extendable datasets.
+// Datasets in files opened for SWMR reading are refreshed from the file first.
+func (h *HdfReaderSync) Refresh() error {
+	h.busy.Lock()
+	defer h.busy.Unlock()
+	return h.refresh()
+}
+
+func (h *HdfReaderSync) refresh() error {
+	f := h.dset.File()
+	if f == nil {
+		return newHdfError("refresh", h.datapath, nil, errors.New("unable to get the dataset file"))
+	}
+	defer f.Close()
+	intent, err := fileIntent(f.ID())
+	if err == nil && intent&swmrReadFlag != 0 {
+		err = refreshDataset(h.dset.ID())
+		if err != nil {
+			return newHdfError("refresh", h.datapath, nil, err)
+		}
+	}
+	space := h.dset.Space()
+	defer space.Close()
+	dims, maxdims, err := space.SimpleExtentDims()
+	if err != nil {
+		return newHdfError("read dimensions", h.dset.Name(), nil, err)
+	}
+	h.dims = dims
+	h.maxdims = maxdims
+	return nil
+}
+
 func (h *HdfReaderSync) DatasetType() (*hdf5.Datatype, error) {
+	h.busy.Lock()
+	defer h.busy.Unlock()
 	return h.dset.Datatype()
 }
 
+// Read, ReadInto, ReadSubset and ReadSubsetInto serialize on h.busy with the context
+// variants, so they wait for reads abandoned by a cancelled context to finish
+
 func (h *HdfReaderSync) Read() (*HdfData, error) {
+	h.busy.Lock()
+	defer h.busy.Unlock()
+	return h.read(context.Background())
+}
+
+func (h *HdfReaderSync) read(ctx context.Context) (*HdfData, error) {
+	if h.stringBuffer() {
+		var strs []string
+		err := h.retry(ctx, "read", func() (err error) {
+			strs, err = h.readStrings(0, 0, int(arraySize(h.dims)))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &HdfData{DsetDims: h.dims, Dims: h.dims, Buffer: &strs}, nil
+	}
 	dest, err := makeDataset(h.dims, h.dtype, h.strsizes.RowSize())
 	if err != nil {
 		return nil, err
 	}
-	err = h.dset.Read(dest)
+	err = h.retry(ctx, "read", func() error {
+		return h.readSelection(dest, nil, nil, arraySize(h.dims))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -550,81 +1057,259 @@ func (h *HdfReaderSync) Read() (*HdfData, error) {
 		DsetDims: h.dims,
 		Dims:     h.dims,
 		Buffer:   dest,
+		Strsizes: h.strsizes,
 	}
 	return &data, nil
 }
 
 func (h *HdfReaderSync) ReadInto(dest interface{}) error {
-	err := h.dset.Read(dest)
-	if err != nil {
-		return err
+	h.busy.Lock()
+	defer h.busy.Unlock()
+	return h.readInto(context.Background(), dest)
+}
+
+func (h *HdfReaderSync) readInto(ctx context.Context, dest interface{}) error {
+	if h.stringBuffer() {
+		data, err := h.read(ctx)
+		if err != nil {
+			return err
+		}
+		return setStrings(dest, data.Buffer.(*[]string))
 	}
-	return nil
+	return h.retry(ctx, "read", func() error {
+		return h.readSelection(dest, nil, nil, arraySize(h.dims))
+	})
 }
 
 func (h *HdfReaderSync) ReadSubset(rowrange []int, colrange []int) (*HdfData, error) {
-	filespace := h.dset.Space()
-	defer filespace.Close()
+	h.busy.Lock()
+	defer h.busy.Unlock()
+	return h.readSubset(context.Background(), rowrange, colrange)
+}
 
-	memspace, dims, err := h.getMemspace(filespace, rowrange, colrange)
-	if err != nil {
-		return nil, err
+func (h *HdfReaderSync) readSubset(ctx context.Context, rowrange []int, colrange []int) (*HdfData, error) {
+	logger().Debug("reading subset", "file", h.filepath, "datapath", h.datapath, "rows", rowrange, "cols", colrange)
+	var data *HdfData
+	err := h.retry(ctx, "read subset", func() error {
+		filespace := h.dset.Space()
+		defer filespace.Close()
+
+		memspace, dims, err := h.getMemspace(filespace, rowrange, colrange)
+		if err != nil {
+			return err
+		}
+		defer memspace.Close()
+
+		var dest interface{}
+		if h.stringBuffer() {
+			strs, err := h.readStrings(memspace.ID(), filespace.ID(), int(arraySize(dims)))
+			if err != nil {
+				return err
+			}
+			dest = &strs
+		} else {
+			dest, err = makeDataset(dims, h.dtype, h.strsizes.RowSize())
+			if err != nil {
+				return err
+			}
+			err = h.readSelection(dest, memspace, filespace, arraySize(dims))
+			if err != nil {
+				return err
+			}
+		}
+		data = &HdfData{
+			DsetDims: h.dims,
+			Dims:     dims,
+			Buffer:   dest,
+			Strsizes: h.strsizes}
+		return nil
+	})
+	return data, err
+}
+
+func (h *HdfReaderSync) ReadSubsetInto(dest interface{}, rowrange []int, colrange []int) error {
+	h.busy.Lock()
+	defer h.busy.Unlock()
+	return h.readSubsetInto(context.Background(), dest, rowrange, colrange)
+}
+
+func (h *HdfReaderSync) readSubsetInto(ctx context.Context, dest interface{}, rowrange []int, colrange []int) error {
+	if h.stringBuffer() {
+		data, err := h.readSubset(ctx, rowrange, colrange)
+		if err != nil {
+			return err
+		}
+		return setStrings(dest, data.Buffer.(*[]string))
 	}
-	defer memspace.Close()
+	return h.retry(ctx, "read subset", func() error {
+		filespace := h.dset.Space()
+		defer filespace.Close()
 
-	dest, err := makeDataset(dims, h.dtype, h.strsizes.RowSize())
-	if err != nil {
-		return nil, err
+		memspace, dims, err := h.getMemspace(filespace, rowrange, colrange)
+		if err != nil {
+			return err
+		}
+		defer memspace.Close()
+
+		return h.readSelection(dest, memspace, filespace, arraySize(dims))
+	})
+}
+
+// stringBuffer reports whether the dataset is read into []string buffers rather than
+// fixed length string bytes or numeric slices
+func (h *HdfReaderSync) stringBuffer() bool {
+	return h.varlen || h.enum != nil
+}
+
+// readStrings reads n selected variable length strings or enum member names
+func (h *HdfReaderSync) readStrings(memspaceID int64, filespaceID int64, n int) ([]string, error) {
+	if h.enum != nil {
+		return h.enum.readNames(h.dset.ID(), memspaceID, filespaceID, n)
 	}
+	return readVarLenStrings(h.dset.ID(), memspaceID, filespaceID, n)
+}
 
-	err = h.dset.ReadSubset(dest, memspace, filespace)
-	if err != nil {
-		return nil, err
+// Enum returns the name/value mapping of enum datasets, nil for other datatypes
+func (h *HdfReaderSync) Enum() (*HdfEnum, error) {
+	h.busy.Lock()
+	defer h.busy.Unlock()
+	return datasetEnum(h.dset)
+}
+
+// readSelection reads n selected elements into dest.  Numeric elements are converted to
+// the kind of dest, other datatypes are read unconverted.  Nil dataspaces read the entire dataset.
+func (h *HdfReaderSync) readSelection(dest interface{}, memspace *hdf5.Dataspace, filespace *hdf5.Dataspace, n uint) error {
+	memtype := h.memtype
+	if kind := sliceKind(dest); kind != h.dtype {
+		ftype, err := h.dset.Datatype()
+		if err != nil {
+			return err
+		}
+		class := ftype.Class()
+		ftype.Close()
+		memtype = nil
+		if class == hdf5.T_INTEGER || class == hdf5.T_FLOAT || class == hdf5.T_ENUM {
+			memtype, err = readMemDatatype(h.dset, kind, h.allowLossy)
+			if err != nil {
+				return err
+			}
+			if memtype != nil {
+				defer memtype.Close()
+			}
+		}
 	}
-	return &HdfData{
-		DsetDims: h.dims,
-		Dims:     dims,
-		Buffer:   dest}, nil
+	if memtype == nil {
+		//other datatypes are read with the file datatype
+		ftype, err := h.dset.Datatype()
+		if err != nil {
+			return err
+		}
+		defer ftype.Close()
+		memtype = ftype
+	}
+	var memspaceID, filespaceID int64
+	if memspace != nil {
+		memspaceID, filespaceID = memspace.ID(), filespace.ID()
+	}
+	return readDataset(h.dset.ID(), memtype.ID(), memspaceID, filespaceID, dest, int(n))
 }
 
-func (h *HdfReaderSync) ReadSubsetInto(dest interface{}, rowrange []int, colrange []int) error {
-	filespace := h.dset.Space()
-	defer filespace.Close()
+// retry runs a read of a remote file under the file options retry policy.  Reads that
+// fail in the HDF5 I/O or file driver layers are reported as ErrRemoteAccess and the
+// file is reopened before each retry.  Other errors, such as invalid selections or
+// datatype conversions, are returned unchanged and not retried.  Retries stop when ctx is done.
+func (h *HdfReaderSync) retry(ctx context.Context, op string, read func() error) error {
+	if h.fileOptions.Retry == nil || !isRemoteUrl(h.filepath) {
+		return read()
+	}
+	return h.fileOptions.Retry.do(ctx, func() error {
+		err := read()
+		if errors.Is(err, errIOFailure) && errorKind(err) == nil {
+			return newHdfError(op, h.datapath, ErrRemoteAccess, err)
+		}
+		return err
+	}, h.reopen)
+}
 
-	memspace, _, err := h.getMemspace(filespace, rowrange, colrange)
+// reopen replaces the file handle and dataset with newly opened ones.  The reader keeps
+// its current handles when the file or dataset cannot be reopened.
+// Readers using a file supplied by the caller keep their handle.
+func (h *HdfReaderSync) reopen() error {
+	logger().Warn("retrying remote read", "file", h.filepath, "datapath", h.datapath)
+	if h.pool == nil {
+		return nil
+	}
+	h.pool.detach(h.file)
+	f, err := h.pool.Acquire(h.filepath, h.fileOptions)
 	if err != nil {
 		return err
 	}
-	defer memspace.Close()
-
-	err = h.dset.ReadSubset(dest, memspace, filespace)
+	dset, err := openDataset(f, h.datapath)
 	if err != nil {
+		h.pool.Release(f)
 		return err
 	}
+	h.dset.Close()
+	h.pool.Release(h.file)
+	h.file, h.dset = f, dset
 	return nil
 }
 
 func (h *HdfReaderSync) getMemspace(filespace *hdf5.Dataspace, rowrange []int, colrange []int) (*hdf5.Dataspace, []uint, error) {
+	return selectSubset(filespace, h.dims, h.dset.Name(), rowrange, colrange)
+}
+
+// selectSubset selects the inclusive row and column ranges on the filespace and creates
+// the matching memory dataspace.  1-D datasets are treated as a single column.
+func selectSubset(filespace *hdf5.Dataspace, dsetdims []uint, datapath string, rowrange []int, colrange []int) (*hdf5.Dataspace, []uint, error) {
+	err := checkSelection(dsetdims, rowrange, colrange)
+	if err != nil {
+		return nil, []uint{}, newHdfError("select", datapath, ErrOutOfRange, err)
+	}
 	rcount := uint(rowrange[1]-rowrange[0]) + 1
 	colcount := uint(colrange[1]-colrange[0]) + 1
 	offset, stride, count, block := []uint{uint(rowrange[0]), uint(colrange[0])}, []uint{1, 1}, []uint{rcount, colcount}, []uint{1, 1}
-
-	err := filespace.SelectHyperslab(offset[:], stride[:], count[:], block[:])
-	if err != nil {
-		return nil, []uint{}, err
+	dims := []uint{rcount, colcount}
+	if len(dsetdims) == 1 {
+		offset, stride, count, block = offset[:1], stride[:1], count[:1], block[:1]
+		dims = dims[:1]
 	}
 
-	dims, maxdims := []uint{rcount, colcount}, []uint{rcount, colcount}
+	err = filespace.SelectHyperslab(offset, stride, count, block)
 	if err != nil {
 		return nil, []uint{}, err
 	}
-	memspace, err := hdf5.CreateSimpleDataspace(dims[:], maxdims[:])
+
+	memspace, err := hdf5.CreateSimpleDataspace(dims, dims)
 	if err != nil {
 		return nil, []uint{}, err
 	}
 	return memspace, dims, nil
 }
 
+// checkSelection validates inclusive row and column ranges against a 1-D or 2-D dataset
+func checkSelection(dims []uint, rowrange []int, colrange []int) error {
+	if len(rowrange) != 2 || len(colrange) != 2 {
+		return errors.New("row and column ranges require a start and end index")
+	}
+	switch len(dims) {
+	case 1:
+		if colrange[0] != 0 || colrange[1] != 0 {
+			return fmt.Errorf("column range %v is invalid for a 1-D dataset", colrange)
+		}
+	case 2:
+		if colrange[0] < 0 || colrange[1] < colrange[0] || colrange[1] >= int(dims[1]) {
+			return fmt.Errorf("range %v is outside of dimension 1 with size %d", colrange, dims[1])
+		}
+	default:
+		return fmt.Errorf("subset selections require a 1-D or 2-D dataset, dataset has %d dimensions", len(dims))
+	}
+	if rowrange[0] < 0 || rowrange[1] < rowrange[0] || rowrange[1] >= int(dims[0]) {
+		return fmt.Errorf("range %v is outside of dimension 0 with size %d", rowrange, dims[0])
+	}
+	return nil
+}
+
 func makeDataset(dims []uint, dtype reflect.Kind, strbuffersize int) (interface{}, error) {
 	switch dtype {
 	case reflect.Float32:
@@ -633,14 +1318,38 @@ func makeDataset(dims []uint, dtype reflect.Kind, strbuffersize int) (interface{
 	case reflect.Float64:
 		d := make([]float64, arraySize(dims))
 		return &d, nil
+	case reflect.Int8:
+		d := make([]int8, arraySize(dims))
+		return &d, nil
+	case reflect.Int16:
+		d := make([]int16, arraySize(dims))
+		return &d, nil
 	case reflect.Int32:
 		d := make([]int32, arraySize(dims))
 		return &d, nil
+	case reflect.Int64:
+		d := make([]int64, arraySize(dims))
+		return &d, nil
+	case reflect.Uint8:
+		d := make([]uint8, arraySize(dims))
+		return &d, nil
+	case reflect.Uint16:
+		d := make([]uint16, arraySize(dims))
+		return &d, nil
+	case reflect.Uint32:
+		d := make([]uint32, arraySize(dims))
+		return &d, nil
+	case reflect.Uint64:
+		d := make([]uint64, arraySize(dims))
+		return &d, nil
+	case reflect.Bool:
+		d := make([]bool, arraySize(dims))
+		return &d, nil
 	case reflect.String:
 		d := make([]byte, strbuffersize*int(dims[0]))
 		return &d, nil
 	default:
-		return nil, errors.New("Unable to make dataset: invalid datatype")
+		return nil, fmt.Errorf("Unable to make dataset for %s: %w", dtype, ErrUnsupportedDatatype)
 	}
 }
 
@@ -0,0 +1,474 @@
+package hdf5utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+func TestCreateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "create.h5")
+	f, err := CreateFile(path, CreateExclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := f.CreateGroup("/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+	f.Close()
+
+	if _, err := CreateFile(path, CreateExclusive); !errors.Is(err, os.ErrExist) {
+		t.Errorf("create existing file: got %v, want os.ErrExist", err)
+	}
+	f, err = CreateFile(path, CreateTruncate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.LinkExists("/results") {
+		t.Error("truncated file still contains /results")
+	}
+	f.Close()
+
+	f, err = OpenFileRW(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := OpenFileRW(filepath.Join(t.TempDir(), "missing.h5")); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("open missing file: got %v, want ErrFileNotFound", err)
+	}
+
+	for _, url := range []string{"s3://bucket/model/results.h5", "https://example.com/model/results.h5"} {
+		if _, err := CreateFile(url, CreateTruncate); !errors.Is(err, ErrRemoteWrite) {
+			t.Errorf("create %s: got %v, want ErrRemoteWrite", url, err)
+		}
+		if _, err := OpenFileRW(url); !errors.Is(err, ErrRemoteWrite) {
+			t.Errorf("open %s: got %v, want ErrRemoteWrite", url, err)
+		}
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	values := []float64{1, 2, 3, 4}
+	path := fixtureFile(t, "/values", &values, []uint{2, 2})
+	f, err := OpenFile(path)
+	must(t, err)
+	defer f.Close()
+
+	if _, err := OpenFile(filepath.Join(t.TempDir(), "missing.h5")); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("OpenFile of a missing file: got %v, want ErrFileNotFound", err)
+	}
+	if _, err := NewHdfReaderSync("/missing", HdfReadOptions{Dtype: reflect.Float64, File: f}); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("NewHdfReaderSync of a missing dataset: got %v, want ErrDatasetNotFound", err)
+	}
+	if _, err := NewHdfDataset("/group/missing", HdfReadOptions{Dtype: reflect.Float64, File: f}); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("NewHdfDataset of a missing dataset: got %v, want ErrDatasetNotFound", err)
+	}
+	if _, err := NewHdfDataset("/values", HdfReadOptions{Dtype: reflect.String, File: f}); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("read float64 values as strings: got %v, want ErrUnsupportedDatatype", err)
+	}
+	dset, err := NewHdfDataset("/values", HdfReadOptions{Dtype: reflect.Float64, File: f})
+	must(t, err)
+	defer dset.Close()
+	if err := dset.ReadSubset([]int{1, 2}, []int{0, 1}); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ReadSubset past the last row: got %v, want ErrOutOfRange", err)
+	}
+
+	var records []scalarRecord
+	if err := ReadCompoundAttributes(f, "/missing", &records, nil); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("ReadCompoundAttributes of a missing path: got %v, want ErrDatasetNotFound", err)
+	}
+	if _, err := GetAttrMetadata(f, DatasetMetadata, "/missing", ""); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("dataset metadata of a missing path: got %v, want ErrDatasetNotFound", err)
+	}
+	if _, err := GetAttrMetadata(f, GroupMetadata, "/missing", "units"); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("attribute of a missing group: got %v, want ErrDatasetNotFound", err)
+	}
+	if _, err := GetAttrMetadata(f, GroupMetadata, "/", "units"); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("missing attribute: got %v, want ErrDatasetNotFound", err)
+	}
+	gauges := gaugeFixture(t)
+	if _, err := GetAttrMetadata(gauges, CompoundMetadata, "/gauges", "Missing"); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("missing compound member: got %v, want ErrDatasetNotFound", err)
+	}
+	if _, err := ReadStringTable(stringTableFixture(t), "/table", StringTableOptions{HeaderAttribute: "missing"}); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("missing header attribute: got %v, want ErrDatasetNotFound", err)
+	}
+}
+
+func TestRemoteOpenErrors(t *testing.T) {
+	var checks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.UserAgent(), "Go-http-client") {
+			atomic.AddInt32(&checks, 1) //requests classifying a failed open, not those of ROS3
+		}
+		switch r.URL.Path {
+		case "/denied.h5":
+			w.WriteHeader(http.StatusForbidden)
+		case "/broken.h5":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"/missing.h5", ErrFileNotFound},
+		{"/denied.h5", ErrAccessDenied},
+		{"/broken.h5", ErrRemoteAccess},
+	}
+	for _, tc := range tests {
+		atomic.StoreInt32(&checks, 0)
+		options := HdfFileOptions{
+			Credentials: AnonymousCredentialProvider{},
+			Retry:       &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+		}
+		_, err := OpenFileWithOptions(srv.URL+tc.path, options)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.path, err, tc.want)
+		}
+		//missing and denied files are not retried
+		attempts := int32(1)
+		if tc.want == ErrRemoteAccess {
+			attempts = 3
+		}
+		if n := atomic.LoadInt32(&checks); n != attempts {
+			t.Errorf("%s: opened %d times, want %d", tc.path, n, attempts)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	f, err := CreateFile(filepath.Join(t.TempDir(), "watch.h5"), CreateExclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := NewHdfWriterSync("/series", HdfWriteOptions{Dtype: reflect.Float64, Dims: []uint{0, 2}, Extendable: true, File: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	reader, err := NewHdfDataset("/series", HdfReadOptions{Dtype: reflect.Float64, File: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if err := w.Append(&[]float64{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	//the writer appends from onChange so the hdf5 calls stay on the watching goroutine
+	stop := make(chan struct{})
+	changes := [][]uint{}
+	done := make(chan error, 1)
+	go func() {
+		done <- reader.Watch(time.Millisecond, stop, func(dims []uint) bool {
+			changes = append(changes, dims)
+			if len(changes) == 1 {
+				if err := w.Append(&[]float64{5, 6}); err != nil {
+					t.Error(err)
+				}
+			} else {
+				close(stop)
+			}
+			return true
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not return after stop was closed")
+	}
+	if want := [][]uint{{2, 2}, {3, 2}}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+
+	if err := w.Append(&[]float64{7, 8}); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	err = reader.Watch(time.Millisecond, make(chan struct{}), func(dims []uint) bool {
+		calls++
+		return false
+	})
+	if err != nil || calls != 1 || !reflect.DeepEqual(reader.Dims(), []uint{4, 2}) {
+		t.Errorf("got %v after %d calls with dims %v, want one call with dims [4 2]", err, calls, reader.Dims())
+	}
+}
+
+func TestHdfStrSetBytesTo(t *testing.T) {
+	set := NewHdfStrSet(4, 8, 2)
+	if set.RowSize() != 14 {
+		t.Errorf("got row size %d, want 14", set.RowSize())
+	}
+	for col, want := range []int{0, 4, 12} {
+		got, err := set.BytesTo(col)
+		if err != nil || got != want {
+			t.Errorf("column %d: got offset %d, %v, want %d", col, got, err, want)
+		}
+	}
+	if _, err := set.BytesTo(3); err == nil {
+		t.Error("expected an error for a column past the end of the row")
+	}
+
+	var parsed HdfStrSet
+	if err := parsed.FromString(set.ToString()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := parsed.BytesTo(2); got != 12 || parsed.RowSize() != 14 {
+		t.Errorf("parsed set: got offset %d, row size %d", got, parsed.RowSize())
+	}
+
+	//parsing replaces the sizes of a set that is already in use
+	if err := parsed.FromString("3,5"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Cols(), []int{3, 5}) || parsed.RowSize() != 8 {
+		t.Errorf("reparsed set: got sizes %v, row size %d, want [3 5] and 8", parsed.Cols(), parsed.RowSize())
+	}
+	for _, s := range []string{"", "4,x", "4,,2", "0", "4,-1"} {
+		if err := parsed.FromString(s); err == nil {
+			t.Errorf("parse %q: expected an error", s)
+		}
+	}
+	if !reflect.DeepEqual(parsed.Cols(), []int{3, 5}) {
+		t.Errorf("failed parses changed the set to %v", parsed.Cols())
+	}
+}
+
+func TestHdfStrSetGob(t *testing.T) {
+	f := stringTableFixture(t)
+	dset, err := NewHdfDataset("/table", HdfReadOptions{Dtype: reflect.String, File: f, ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+
+	//async reads send HdfData through a gob encoded pipe, the string formats must survive it
+	var pipe bytes.Buffer
+	if err := gob.NewEncoder(&pipe).Encode(dset.Data); err != nil {
+		t.Fatal(err)
+	}
+	var data HdfData
+	if err := gob.NewDecoder(&pipe).Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	sizes := dset.Data.Strsizes
+	if !reflect.DeepEqual(data.Strsizes.Cols(), sizes.Cols()) || data.Strsizes.RowSize() != sizes.RowSize() {
+		t.Fatalf("got sizes %v, want %v", data.Strsizes.Cols(), sizes.Cols())
+	}
+	for col := range sizes.Cols() {
+		if got, want := data.Strsizes.Format(col), sizes.Format(col); got != want {
+			t.Errorf("column %d: got format %+v, want %+v", col, got, want)
+		}
+	}
+
+	//a null terminated field keeps the leading space that trimming would remove
+	set := NewHdfStrSet(4)
+	set.formats = []StringFormat{{StrNullTerm, CharsetASCII}}
+	pipe.Reset()
+	if err := gob.NewEncoder(&pipe).Encode(HdfData{Strsizes: set}); err != nil {
+		t.Fatal(err)
+	}
+	data = HdfData{}
+	if err := gob.NewDecoder(&pipe).Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	if got := data.Strsizes.decode(0, []byte(" ab\x00"), false); got != " ab" {
+		t.Errorf("got %q, want %q", got, " ab")
+	}
+}
+
+// swmrWriterEnv names the file written by TestSWMRWriterProcess when the test binary is
+// run as the writer of TestSWMRWatch.  HDF5 shares files opened twice in one process, so
+// the writer runs in its own process for the reader to have SWMR read access.
+const swmrWriterEnv = "HDF5UTILS_SWMR_WRITER"
+
+// TestSWMRWriterProcess creates a 2 row extendable dataset, reopens it for SWMR writing and
+// appends a row for each line read from stdin, reporting each step on stdout
+func TestSWMRWriterProcess(t *testing.T) {
+	path := os.Getenv(swmrWriterEnv)
+	if path == "" {
+		t.Skip("writer process of TestSWMRWatch")
+	}
+	must(t, createLatestFormatFile(path))
+	f, err := openLatestFormatFile(path, hdf5.F_ACC_RDWR)
+	must(t, err)
+	w, err := NewHdfWriterSync("/series", HdfWriteOptions{Dtype: reflect.Float64, Dims: []uint{0, 2}, Extendable: true, File: f})
+	must(t, err)
+	must(t, w.Append(&[]float64{1, 2, 3, 4}))
+	w.Close()
+	f.Close()
+
+	f, err = openLatestFormatFile(path, hdf5.F_ACC_RDWR|swmrWriteFlag)
+	must(t, err)
+	defer f.Close()
+	w, err = NewHdfWriterSync("/series", HdfWriteOptions{Dtype: reflect.Float64, File: f})
+	must(t, err)
+	defer w.Close()
+	fmt.Println("ready")
+
+	stdin := bufio.NewScanner(os.Stdin)
+	for v := 5.0; stdin.Scan(); v += 2 {
+		must(t, w.Append(&[]float64{v, v + 1}))
+		must(t, f.Flush(hdf5.F_SCOPE_GLOBAL))
+		fmt.Println("appended")
+	}
+}
+
+func TestSWMRWatch(t *testing.T) {
+	if swmrReadFlag == 0 || swmrWriteFlag == 0 {
+		t.Skip("the HDF5 library was built without SWMR support")
+	}
+	path := filepath.Join(t.TempDir(), "swmr.h5")
+	writer := exec.Command(os.Args[0], "-test.run=^TestSWMRWriterProcess$")
+	writer.Env = append(os.Environ(), swmrWriterEnv+"="+path)
+	stdin, err := writer.StdinPipe()
+	must(t, err)
+	stdout, err := writer.StdoutPipe()
+	must(t, err)
+	must(t, writer.Start())
+	defer writer.Wait()
+	defer stdin.Close()
+	lines := bufio.NewScanner(stdout)
+	await := func(want string) {
+		t.Helper()
+		for lines.Scan() {
+			if lines.Text() == want {
+				return
+			}
+		}
+		t.Fatalf("writer process exited before reporting %s", want)
+	}
+	await("ready")
+
+	reader, err := NewHdfDataset("/series", HdfReadOptions{
+		Dtype:       reflect.Float64,
+		Filepath:    path,
+		FileOptions: HdfFileOptions{SWMR: true},
+		Pool:        NewFilePool(0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if dims := reader.Dims(); !reflect.DeepEqual(dims, []uint{2, 2}) {
+		t.Fatalf("got dims %v before the append, want [2 2]", dims)
+	}
+
+	fmt.Fprintln(stdin, "append")
+	await("appended")
+	changes := [][]uint{}
+	err = reader.Watch(time.Millisecond, make(chan struct{}), func(dims []uint) bool {
+		changes = append(changes, dims)
+		return false
+	})
+	if err != nil || !reflect.DeepEqual(changes, [][]uint{{3, 2}}) {
+		t.Fatalf("got changes %v, %v, want [[3 2]]", changes, err)
+	}
+	if err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if got := *reader.Data.Buffer.(*[]float64); !reflect.DeepEqual(got, []float64{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("got %v after the append", got)
+	}
+}
+
+func TestSWMRUnsupported(t *testing.T) {
+	values := []float64{1}
+	path := fixtureFile(t, "/values", &values, []uint{1})
+	flag := swmrReadFlag
+	swmrReadFlag = 0
+	defer func() { swmrReadFlag = flag }()
+	if _, err := OpenFileWithOptions(path, HdfFileOptions{SWMR: true}); !errors.Is(err, errNoSWMR) {
+		t.Errorf("got %v, want errNoSWMR", err)
+	}
+}
+
+// varLenFixture writes a variable length string dataset to a new file and returns the file path
+func varLenFixture(t *testing.T, datapath string, strs []string, dims []uint) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "strings.h5")
+	f, err := CreateFile(path, CreateExclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	space, err := hdf5.CreateSimpleDataspace(dims, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer space.Close()
+	dset, err := f.CreateDataset(datapath, hdf5.T_GO_STRING, space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	if err := writeVarLenStrings(dset.ID(), strs); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadVarLenStrings(t *testing.T) {
+	strs := []string{
+		"a", "bb",
+		"", "ccc dd ",
+		"déjà", "f",
+	}
+	path := varLenFixture(t, "/names", strs, []uint{3, 2})
+	dset, err := NewHdfDataset("/names", HdfReadOptions{Dtype: reflect.String, Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+
+	if err := dset.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if got := *dset.Data.Buffer.(*[]string); !reflect.DeepEqual(got, strs) {
+		t.Errorf("read: got %q, want %q", got, strs)
+	}
+	row := []string{}
+	if err := dset.ReadRow(1, &row); err != nil || !reflect.DeepEqual(row, []string{"", "ccc dd "}) {
+		t.Errorf("row 1: got %q, %v", row, err)
+	}
+	col := []string{}
+	if err := dset.ReadColumn(1, &col); err != nil || !reflect.DeepEqual(col, []string{"bb", "ccc dd ", "f"}) {
+		t.Errorf("column 1: got %q, %v", col, err)
+	}
+
+	if err := dset.ReadSubset([]int{1, 2}, []int{0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if got := *dset.Data.Buffer.(*[]string); !reflect.DeepEqual(got, []string{"", "déjà"}) {
+		t.Errorf("subset: got %q", got)
+	}
+	if !reflect.DeepEqual(dset.Data.Dims, []uint{2, 1}) {
+		t.Errorf("subset: got dims %v, want [2 1]", dset.Data.Dims)
+	}
+}
@@ -0,0 +1,249 @@
+package hdf5utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// element size in bytes of the numeric kinds read into typed buffers
+var kindSizes map[reflect.Kind]uint = map[reflect.Kind]uint{
+	reflect.Int8:    1,
+	reflect.Int16:   2,
+	reflect.Int32:   4,
+	reflect.Int64:   8,
+	reflect.Uint8:   1,
+	reflect.Uint16:  2,
+	reflect.Uint32:  4,
+	reflect.Uint64:  8,
+	reflect.Float32: 4,
+	reflect.Float64: 8,
+	reflect.Bool:    1,
+}
+
+// boolDatatype creates the enum used for booleans, an int8 enum with the members
+// FALSE=0 and TRUE=1 matching the layout written by h5py
+func boolDatatype() (*hdf5.Datatype, error) {
+	dt, err := hdf5.CreateDatatype(hdf5.T_ENUM, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range []struct {
+		name  string
+		value int64
+	}{{"FALSE", 0}, {"TRUE", 1}} {
+		if err := enumInsert(dt.ID(), m.name, m.value); err != nil {
+			dt.Close()
+			return nil, err
+		}
+	}
+	return dt, nil
+}
+
+// readMemDatatype returns the memory datatype used to read the dataset into a buffer of kind.
+// Integer and float datasets are converted by HDF5 to the native type of kind, enums use
+// the file datatype.  Booleans are only read from 1 byte FALSE/TRUE enums since other
+// bytes may hold values that are not valid Go bools.  Narrowing conversions that may lose
+// values fail with ErrLossyConversion unless allowLossy is set.  A nil datatype is
+// returned for kinds that are not numeric.
+func readMemDatatype(dset *hdf5.Dataset, kind reflect.Kind, allowLossy bool) (*hdf5.Datatype, error) {
+	size, ok := kindSizes[kind]
+	if !ok {
+		return nil, nil
+	}
+	ftype, err := dset.Datatype()
+	if err != nil {
+		return nil, newHdfError("read datatype", dset.Name(), nil, err)
+	}
+	err = checkConversion(ftype, kind, allowLossy)
+	if err != nil {
+		ftype.Close()
+		return nil, newHdfError("open dataset", dset.Name(), nil, err)
+	}
+	if ftype.Class() == hdf5.T_ENUM && ftype.Size() == size {
+		return ftype, nil
+	}
+	ftype.Close()
+	return hdf5.NewDataTypeFromType(kindTypes[kind])
+}
+
+// checkConversion reports whether elements of ftype can be converted to kind
+func checkConversion(ftype *hdf5.Datatype, kind reflect.Kind, allowLossy bool) error {
+	class, fsize, size := ftype.Class(), ftype.Size(), kindSizes[kind]
+	unsupported := fmt.Errorf("%d byte %s elements cannot be read as %s: %w", fsize, typeClassName(class), kind, ErrUnsupportedDatatype)
+	mismatch := fmt.Errorf("%d byte %s elements cannot be read as %s: %w", fsize, typeClassName(class), kind, ErrDatatypeMismatch)
+	lossy := fmt.Errorf("reading %d byte %s elements as %s may lose values: %w", fsize, typeClassName(class), kind, ErrLossyConversion)
+
+	switch class {
+	case hdf5.T_ENUM:
+		//HDF5 only converts enums to other enums so they are read unconverted
+		if kind == reflect.Bool {
+			if isBoolEnum(ftype) {
+				return nil
+			}
+			return mismatch
+		}
+		if isIntKind(kind) && fsize == size {
+			signed, err := typeSigned(ftype.ID())
+			if err != nil {
+				return err
+			}
+			if signed == isSignedKind(kind) {
+				return nil
+			}
+			return mismatch
+		}
+		return unsupported
+
+	case hdf5.T_FLOAT:
+		switch {
+		case kind == reflect.Float64 || (kind == reflect.Float32 && fsize <= 4):
+			return nil
+		case kind == reflect.Bool:
+			return unsupported
+		}
+
+	case hdf5.T_INTEGER:
+		signed, err := typeSigned(ftype.ID())
+		if err != nil {
+			return err
+		}
+		switch {
+		case kind == reflect.Bool:
+			return mismatch
+		case kind == reflect.Float64 && fsize <= 4, kind == reflect.Float32 && fsize <= 2:
+			return nil
+		case isSignedKind(kind) && (size > fsize || (signed && size == fsize)):
+			return nil
+		case isUnsignedKind(kind) && !signed && size >= fsize:
+			return nil
+		}
+
+	default:
+		return unsupported
+	}
+	if allowLossy {
+		return nil
+	}
+	return lossy
+}
+
+func isSignedKind(kind reflect.Kind) bool {
+	return kind == reflect.Int8 || kind == reflect.Int16 || kind == reflect.Int32 || kind == reflect.Int64
+}
+
+func isUnsignedKind(kind reflect.Kind) bool {
+	return kind == reflect.Uint8 || kind == reflect.Uint16 || kind == reflect.Uint32 || kind == reflect.Uint64
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return isSignedKind(kind) || isUnsignedKind(kind)
+}
+
+// inferKind maps the dataset datatype to the Go kind it is read as by default.  Integer
+// and float datasets map to the kind of the same size and sign, 1 byte FALSE/TRUE enums
+// to bool, other enums to their base integer kind, and fixed or variable length strings
+// and compounds of strings to string.
+func inferKind(dset *hdf5.Dataset) (reflect.Kind, error) {
+	dtype, err := dset.Datatype()
+	if err != nil {
+		return reflect.Invalid, newHdfError("read datatype", dset.Name(), nil, err)
+	}
+	defer dtype.Close()
+
+	class, size := dtype.Class(), dtype.Size()
+	switch class {
+	case hdf5.T_FLOAT:
+		if k := numericKind(hdf5.T_FLOAT, size, true); k != reflect.Invalid {
+			return k, nil
+		}
+
+	case hdf5.T_INTEGER, hdf5.T_ENUM:
+		if class == hdf5.T_ENUM && isBoolEnum(dtype) {
+			return reflect.Bool, nil
+		}
+		signed, err := typeSigned(dtype.ID())
+		if err != nil {
+			return reflect.Invalid, newHdfError("read datatype", dset.Name(), nil, err)
+		}
+		if k := numericKind(hdf5.T_INTEGER, size, signed); k != reflect.Invalid {
+			return k, nil
+		}
+
+	case hdf5.T_STRING:
+		return reflect.String, nil
+
+	case hdf5.T_COMPOUND:
+		ctype := hdf5.CompoundType{*dtype}
+		n := ctype.NMembers()
+		strs := n > 0
+		for i := 0; i < n; i++ {
+			strs = strs && ctype.MemberClass(i) == hdf5.T_STRING
+		}
+		if strs {
+			return reflect.String, nil
+		}
+	}
+	return reflect.Invalid, newHdfError("open dataset", dset.Name(), ErrUnsupportedDatatype,
+		fmt.Errorf("no Go kind for %d byte %s elements", size, typeClassName(class)))
+}
+
+// numericKind returns the Go kind of integer or float elements of size bytes, reflect.Invalid
+// when there is none
+func numericKind(class hdf5.TypeClass, size uint, signed bool) reflect.Kind {
+	switch class {
+	case hdf5.T_FLOAT:
+		switch size {
+		case 4:
+			return reflect.Float32
+		case 8:
+			return reflect.Float64
+		}
+	case hdf5.T_INTEGER:
+		kinds := map[uint][2]reflect.Kind{
+			1: {reflect.Uint8, reflect.Int8},
+			2: {reflect.Uint16, reflect.Int16},
+			4: {reflect.Uint32, reflect.Int32},
+			8: {reflect.Uint64, reflect.Int64},
+		}
+		if k, ok := kinds[size]; ok {
+			if signed {
+				return k[1]
+			}
+			return k[0]
+		}
+	}
+	return reflect.Invalid
+}
+
+// isBoolEnum reports whether an enum has the 1 byte FALSE/TRUE layout written for booleans
+func isBoolEnum(dtype *hdf5.Datatype) bool {
+	etype := hdf5.CompoundType{*dtype} //member names and counts apply to enums as well
+	if dtype.Size() != 1 || etype.NMembers() != 2 {
+		return false
+	}
+	names := strings.ToUpper(etype.MemberName(0)) + "," + strings.ToUpper(etype.MemberName(1))
+	return names == "FALSE,TRUE" || names == "TRUE,FALSE"
+}
+
+func typeClassName(class hdf5.TypeClass) string {
+	names := map[hdf5.TypeClass]string{
+		hdf5.T_INTEGER:   "integer",
+		hdf5.T_FLOAT:     "float",
+		hdf5.T_TIME:      "time",
+		hdf5.T_STRING:    "string",
+		hdf5.T_BITFIELD:  "bitfield",
+		hdf5.T_OPAQUE:    "opaque",
+		hdf5.T_COMPOUND:  "compound",
+		hdf5.T_REFERENCE: "reference",
+		hdf5.T_ENUM:      "enum",
+		hdf5.T_VLEN:      "variable length",
+		hdf5.T_ARRAY:     "array",
+	}
+	if name, ok := names[class]; ok {
+		return name
+	}
+	return fmt.Sprintf("class %d", class)
+}
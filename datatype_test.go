@@ -0,0 +1,200 @@
+package hdf5utils
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadIntegerSign(t *testing.T) {
+	values := []uint32{1, 2, 4000000000}
+	path := fixtureFile(t, "/values", &values, []uint{3})
+
+	read := func(kind reflect.Kind) (*HdfData, error) {
+		dset, err := NewHdfDataset("/values", HdfReadOptions{Dtype: kind, Filepath: path, Pool: NewFilePool(0), ReadOnCreate: true})
+		if err != nil {
+			return nil, err
+		}
+		defer dset.Close()
+		return dset.Data, nil
+	}
+
+	for _, kind := range []reflect.Kind{reflect.Int32, reflect.Int8, reflect.Uint16} {
+		if _, err := read(kind); !errors.Is(err, ErrLossyConversion) {
+			t.Errorf("read uint32 as %s: got %v, want ErrLossyConversion", kind, err)
+		}
+	}
+	data, err := read(reflect.Int64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *data.Buffer.(*[]int64); !reflect.DeepEqual(got, []int64{1, 2, 4000000000}) {
+		t.Errorf("read uint32 as int64: got %v", got)
+	}
+	data, err = read(reflect.Uint32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *data.Buffer.(*[]uint32); !reflect.DeepEqual(got, values) {
+		t.Errorf("read uint32 as uint32: got %v", got)
+	}
+}
+
+func TestReadBool(t *testing.T) {
+	flags := []bool{true, false, true}
+	path := fixtureFile(t, "/flags", &flags, []uint{3})
+	dset, err := NewHdfDataset("/flags", HdfReadOptions{Dtype: reflect.Bool, Filepath: path, Pool: NewFilePool(0), ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	if got := *dset.Data.Buffer.(*[]bool); !reflect.DeepEqual(got, flags) {
+		t.Errorf("got %v, want %v", got, flags)
+	}
+
+	bytes := []uint8{0, 1, 2}
+	path = fixtureFile(t, "/bytes", &bytes, []uint{3})
+	_, err = NewHdfDataset("/bytes", HdfReadOptions{Dtype: reflect.Bool, Filepath: path, Pool: NewFilePool(0)})
+	if !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("read uint8 as bool: got %v, want ErrDatatypeMismatch", err)
+	}
+}
+
+func TestReadKinds(t *testing.T) {
+	tests := []struct {
+		src    interface{} //2x2 values
+		row1   interface{}
+		column interface{}
+	}{
+		{&[]int8{-1, 2, -3, 4}, []int8{-3, 4}, []int8{2, 4}},
+		{&[]int16{-1, 2, -300, 400}, []int16{-300, 400}, []int16{2, 400}},
+		{&[]int64{-1, 2, -3e12, 4e12}, []int64{-3e12, 4e12}, []int64{2, 4e12}},
+		{&[]uint8{1, 2, 3, 255}, []uint8{3, 255}, []uint8{2, 255}},
+		{&[]uint16{1, 2, 3, 65535}, []uint16{3, 65535}, []uint16{2, 65535}},
+		{&[]uint64{1, 2, 3, 1 << 63}, []uint64{3, 1 << 63}, []uint64{2, 1 << 63}},
+		{&[]bool{true, false, false, true}, []bool{false, true}, []bool{false, true}},
+	}
+	for _, tc := range tests {
+		kind := sliceKind(tc.src)
+		path := fixtureFile(t, "/values", tc.src, []uint{2, 2})
+		dset, err := NewHdfDataset("/values", HdfReadOptions{Dtype: kind, Filepath: path, Pool: NewFilePool(0), ReadOnCreate: true})
+		if err != nil {
+			t.Errorf("%s: %v", kind, err)
+			continue
+		}
+		row := reflect.New(reflect.TypeOf(tc.row1))
+		if err := dset.ReadRow(1, row.Interface()); err != nil || !reflect.DeepEqual(row.Elem().Interface(), tc.row1) {
+			t.Errorf("%s row 1: got %v, %v, want %v", kind, row.Elem(), err, tc.row1)
+		}
+		col := reflect.New(reflect.TypeOf(tc.column))
+		if err := dset.ReadColumn(1, col.Interface()); err != nil || !reflect.DeepEqual(col.Elem().Interface(), tc.column) {
+			t.Errorf("%s column 1: got %v, %v, want %v", kind, col.Elem(), err, tc.column)
+		}
+
+		//async reads send HdfData through a gob encoded pipe, which needs the buffer type registered
+		var pipe bytes.Buffer
+		if err := gob.NewEncoder(&pipe).Encode(dset.Data); err != nil {
+			t.Errorf("%s: encode: %v", kind, err)
+		} else {
+			var data HdfData
+			if err := gob.NewDecoder(&pipe).Decode(&data); err != nil {
+				t.Errorf("%s: decode: %v", kind, err)
+			} else if !reflect.DeepEqual(data.Buffer, tc.src) || !reflect.DeepEqual(data.Dims, []uint{2, 2}) {
+				t.Errorf("%s: decoded %v with dims %v", kind, data.Buffer, data.Dims)
+			}
+		}
+		dset.Close()
+	}
+}
+
+func TestReadEnumAsInteger(t *testing.T) {
+	f := enumFixture(t)
+	read := func(kind reflect.Kind) (*HdfData, error) {
+		dset, err := NewHdfDataset("/status", HdfReadOptions{Dtype: kind, File: f, ReadOnCreate: true})
+		if err != nil {
+			return nil, err
+		}
+		defer dset.Close()
+		return dset.Data, nil
+	}
+
+	data, err := read(reflect.Int8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *data.Buffer.(*[]int8); !reflect.DeepEqual(got, []int8{0, -1, 2, 0}) {
+		t.Errorf("read an int8 enum as int8: got %v", got)
+	}
+	if _, err := read(reflect.Uint8); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("read a signed enum as uint8: got %v, want ErrDatatypeMismatch", err)
+	}
+	if _, err := read(reflect.Bool); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("read a status enum as bool: got %v, want ErrDatatypeMismatch", err)
+	}
+	if _, err := read(reflect.Float64); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("read an enum as float64: got %v, want ErrUnsupportedDatatype", err)
+	}
+}
+
+func TestInferKind(t *testing.T) {
+	f := newFixture(t, "kinds.h5")
+	for datapath, src := range map[string]interface{}{
+		"/int16":   &[]int16{-1, 2},
+		"/uint64":  &[]uint64{1, 2},
+		"/float32": &[]float32{1.5, 2.5},
+		"/bool":    &[]bool{true, false},
+		"/fixed":   [][]string{{"ab", "cd"}, {"ef", "gh"}},
+		"/mixed":   [][]string{{"a", "bcd"}, {"ef", "g"}},
+	} {
+		options := HdfWriteOptions{File: f}
+		if _, ok := src.([][]string); ok {
+			options.Dtype = reflect.String
+		}
+		w, err := NewHdfWriterSync(datapath, options)
+		must(t, err)
+		err = w.WriteFrom(src)
+		w.Close()
+		must(t, err)
+	}
+
+	tests := []struct {
+		datapath string
+		want     reflect.Kind
+	}{
+		{"/int16", reflect.Int16},
+		{"/uint64", reflect.Uint64},
+		{"/float32", reflect.Float32},
+		{"/bool", reflect.Bool},
+		{"/fixed", reflect.String},
+		{"/mixed", reflect.String},
+	}
+	for _, tc := range tests {
+		dset, err := NewHdfDataset(tc.datapath, HdfReadOptions{File: f, ReadOnCreate: true})
+		if err != nil {
+			t.Errorf("%s: %v", tc.datapath, err)
+			continue
+		}
+		if got := dset.Dtype(); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.datapath, got, tc.want)
+		}
+		dset.Close()
+	}
+
+	varlen := varLenFixture(t, "/names", []string{"a", "bb"}, []uint{2})
+	dset, err := NewHdfDataset("/names", HdfReadOptions{Filepath: varlen, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	if got := dset.Dtype(); got != reflect.String {
+		t.Errorf("variable length strings: got %s, want string", got)
+	}
+
+	//compounds with numeric members have no Go kind
+	gauges := gaugeFixture(t)
+	if _, err := NewHdfDataset("/gauges", HdfReadOptions{File: gauges}); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("infer a mixed compound: got %v, want ErrUnsupportedDatatype", err)
+	}
+}
@@ -0,0 +1,127 @@
+package hdf5utils
+
+import (
+	"fmt"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// EnumMember is a symbolic name of an HDF5 enum and its integer value
+type EnumMember struct {
+	Name  string
+	Value int64
+}
+
+// HdfEnum is the name/value mapping of an HDF5 enum datatype
+type HdfEnum struct {
+	Size      uint //byte size of the base integer type
+	Signed    bool
+	BigEndian bool //byte order of the base integer type in the file
+	Members   []EnumMember
+}
+
+// Name returns the name of the member with value
+func (e *HdfEnum) Name(value int64) (string, bool) {
+	for _, m := range e.Members {
+		if m.Value == value {
+			return m.Name, true
+		}
+	}
+	return "", false
+}
+
+// Value returns the value of the member with name
+func (e *HdfEnum) Value(name string) (int64, bool) {
+	for _, m := range e.Members {
+		if m.Name == name {
+			return m.Value, true
+		}
+	}
+	return 0, false
+}
+
+// readEnum returns the name/value mapping of an enum datatype
+func readEnum(dtype *hdf5.Datatype) (*HdfEnum, error) {
+	if dtype.Class() != hdf5.T_ENUM {
+		return nil, fmt.Errorf("%s datatype is not an enum: %w", typeClassName(dtype.Class()), ErrUnsupportedDatatype)
+	}
+	signed, err := typeSigned(dtype.ID())
+	if err != nil {
+		return nil, err
+	}
+	bigEndian, err := typeBigEndian(dtype.ID())
+	if err != nil {
+		return nil, err
+	}
+	members, err := enumMembers(dtype.ID())
+	if err != nil {
+		return nil, err
+	}
+	return &HdfEnum{dtype.Size(), signed, bigEndian, members}, nil
+}
+
+// datasetEnum returns the enum mapping of the dataset datatype, nil when the dataset is not an enum
+func datasetEnum(dset *hdf5.Dataset) (*HdfEnum, error) {
+	dtype, err := dset.Datatype()
+	if err != nil {
+		return nil, newHdfError("read datatype", dset.Name(), nil, err)
+	}
+	defer dtype.Close()
+	if dtype.Class() != hdf5.T_ENUM {
+		return nil, nil
+	}
+	enum, err := readEnum(dtype)
+	if err != nil {
+		return nil, newHdfError("read enum", dset.Name(), nil, err)
+	}
+	return enum, nil
+}
+
+// indexDatatype creates an int64 enum with the member names of e valued by member index.
+// HDF5 converts enums by name so reading with it returns the index of each member, values
+// without a member convert to -1.
+func (e *HdfEnum) indexDatatype() (*hdf5.Datatype, error) {
+	dt, err := hdf5.CreateDatatype(hdf5.T_ENUM, 8)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range e.Members {
+		if err := enumInsert(dt.ID(), m.Name, int64(i)); err != nil {
+			dt.Close()
+			return nil, err
+		}
+	}
+	return dt, nil
+}
+
+// readNames reads n selected elements of an enum dataset as member names.  memspaceID and
+// filespaceID select the elements to read, 0 reads the entire dataset.
+func (e *HdfEnum) readNames(dsetID int64, memspaceID int64, filespaceID int64, n int) ([]string, error) {
+	memtype, err := e.indexDatatype()
+	if err != nil {
+		return nil, err
+	}
+	defer memtype.Close()
+	idx := make([]int64, n)
+	err = readDataset(dsetID, memtype.ID(), memspaceID, filespaceID, &idx, n)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, n)
+	for i, j := range idx {
+		if j < 0 || int(j) >= len(e.Members) {
+			return nil, fmt.Errorf("element %d is not a member of the enum: %w", i, ErrOutOfRange)
+		}
+		names[i] = e.Members[j].Name
+	}
+	return names, nil
+}
+
+// decode returns the value of an enum element stored in b in the byte order of the file
+func (e *HdfEnum) decode(b []byte) int64 {
+	b = b[:e.Size]
+	if e.BigEndian {
+		b = reverseBytes(b)
+	}
+	return Ifb(b, e.Signed)
+}
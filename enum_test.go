@@ -0,0 +1,278 @@
+package hdf5utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+func TestHdfEnumDecode(t *testing.T) {
+	tests := []struct {
+		enum HdfEnum
+		b    []byte
+		want int64
+	}{
+		{HdfEnum{Size: 1, Signed: true}, []byte{0xfe, 0xff}, -2},
+		{HdfEnum{Size: 2}, []byte{0x01, 0x02}, 0x0201},
+		{HdfEnum{Size: 2, BigEndian: true}, []byte{0x01, 0x02}, 0x0102},
+		{HdfEnum{Size: 4, Signed: true, BigEndian: true}, []byte{0xff, 0xff, 0xff, 0xfd}, -3},
+		{HdfEnum{Size: 4, BigEndian: true}, []byte{0x00, 0x00, 0x01, 0x00, 0xaa}, 256},
+		{HdfEnum{Size: 8, Signed: true, BigEndian: true}, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, -1},
+	}
+	for i, tc := range tests {
+		if got := tc.enum.decode(tc.b); got != tc.want {
+			t.Errorf("case %d: got %d, want %d", i, got, tc.want)
+		}
+	}
+}
+
+func TestEnumUnpackTable(t *testing.T) {
+	signed := &HdfEnum{Size: 2, Signed: true}
+	unsigned := &HdfEnum{Size: 2}
+	tests := []struct {
+		enum *HdfEnum
+		kind reflect.Kind
+		tag  string
+		want error
+	}{
+		{signed, reflect.Int16, "", nil},
+		{signed, reflect.Int64, "value", nil},
+		{signed, reflect.Int8, "", ErrDatatypeMismatch},
+		{signed, reflect.Uint32, "", ErrDatatypeMismatch},
+		{unsigned, reflect.Uint16, "", nil},
+		{unsigned, reflect.Int32, "", nil},
+		{unsigned, reflect.Int16, "", ErrDatatypeMismatch},
+		{unsigned, reflect.Uint8, "", ErrDatatypeMismatch},
+		{unsigned, reflect.Float64, "", ErrUnsupportedDatatype},
+		{unsigned, reflect.String, "name", nil},
+		{unsigned, reflect.String, "", ErrDatatypeMismatch},
+		{unsigned, reflect.Int32, "name", ErrDatatypeMismatch},
+	}
+	for i, tc := range tests {
+		fm := FieldMetadata{FieldName: "Status", FieldType: tc.kind, Enum: tc.tag}
+		_, err := enumUnpackTable(0, fm, tc.enum)
+		if (tc.want == nil && err != nil) || (tc.want != nil && !errors.Is(err, tc.want)) {
+			t.Errorf("case %d (%s): got %v, want %v", i, tc.kind, err, tc.want)
+		}
+	}
+}
+
+type gaugeStatus struct {
+	Name   string  `hdf:"Name" strlen:"8"`
+	Status string  `hdf:"Status,enum=name"`
+	Level  float64 `hdf:"Level"`
+}
+
+type gaugeCode struct {
+	Name   string  `hdf:"Name" strlen:"8"`
+	Status int16   `hdf:"Status"`
+	Level  float64 `hdf:"Level"`
+}
+
+// gaugeFixture writes a compound dataset with a fixed length string, an int8 enum and a
+// float64 member to a new file and returns the open file
+func gaugeFixture(t *testing.T) *hdf5.File {
+	t.Helper()
+	f := newFixture(t, "gauges.h5")
+
+	name, err := hdf5.T_C_S1.Copy()
+	must(t, err)
+	defer name.Close()
+	must(t, name.SetSize(8))
+	status, err := hdf5.CreateDatatype(hdf5.T_ENUM, 1)
+	must(t, err)
+	defer status.Close()
+	must(t, enumInsert(status.ID(), "OK", 0))
+	must(t, enumInsert(status.ID(), "FAULT", -1))
+	must(t, enumInsert(status.ID(), "OFFLINE", 2))
+	ctype, err := hdf5.NewCompoundType(17)
+	must(t, err)
+	defer ctype.Close()
+	must(t, ctype.Insert("Name", 0, name))
+	must(t, ctype.Insert("Status", 8, status))
+	must(t, ctype.Insert("Level", 9, hdf5.T_IEEE_F64LE))
+
+	space, err := hdf5.CreateSimpleDataspace([]uint{2}, nil)
+	must(t, err)
+	defer space.Close()
+	dset, err := f.CreateDataset("/gauges", &ctype.Datatype, space)
+	must(t, err)
+	defer dset.Close()
+
+	raw := []byte{}
+	for _, g := range []struct {
+		name   string
+		status int8
+		level  float64
+	}{{"gauge1", 0, 1.5}, {"gauge2", -1, -0.25}} {
+		record := make([]byte, 17)
+		copy(record, g.name)
+		record[8] = byte(g.status)
+		binary.LittleEndian.PutUint64(record[9:], math.Float64bits(g.level))
+		raw = append(raw, record...)
+	}
+	must(t, dset.Write(&raw))
+	return f
+}
+
+func TestReadCompoundEnum(t *testing.T) {
+	f := gaugeFixture(t)
+
+	var names []gaugeStatus
+	if err := ReadCompoundAttributes(f, "/gauges", &names, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []gaugeStatus{{"gauge1", "OK", 1.5}, {"gauge2", "FAULT", -0.25}}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %+v, want %+v", names, want)
+	}
+
+	var codes []gaugeCode
+	if err := ReadCompoundAttributes(f, "/gauges", &codes, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 2 || codes[0].Status != 0 || codes[1].Status != -1 {
+		t.Errorf("got %+v, want status values 0 and -1", codes)
+	}
+
+	var unsigned []struct {
+		Name   string  `hdf:"Name" strlen:"8"`
+		Status uint8   `hdf:"Status"`
+		Level  float64 `hdf:"Level"`
+	}
+	if err := ReadCompoundAttributes(f, "/gauges", &unsigned, nil); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("read a signed enum into uint8: got %v, want ErrDatatypeMismatch", err)
+	}
+}
+
+// enumFixture writes an int8 enum with the members OK=0, FAULT=-1 and OFFLINE=2 to the
+// 2x2 dataset "/status" and the 1-D dataset "/unmapped", which holds a value without a member
+func enumFixture(t *testing.T) *hdf5.File {
+	t.Helper()
+	f := newFixture(t, "status.h5")
+	status, err := hdf5.CreateDatatype(hdf5.T_ENUM, 1)
+	must(t, err)
+	defer status.Close()
+	must(t, enumInsert(status.ID(), "OK", 0))
+	must(t, enumInsert(status.ID(), "FAULT", -1))
+	must(t, enumInsert(status.ID(), "OFFLINE", 2))
+
+	for _, d := range []struct {
+		datapath string
+		dims     []uint
+		values   []int8
+	}{
+		{"/status", []uint{2, 2}, []int8{0, -1, 2, 0}},
+		{"/unmapped", []uint{2}, []int8{0, 5}},
+	} {
+		space, err := hdf5.CreateSimpleDataspace(d.dims, nil)
+		must(t, err)
+		defer space.Close()
+		dset, err := f.CreateDataset(d.datapath, status, space)
+		must(t, err)
+		defer dset.Close()
+		must(t, dset.Write(&d.values)) //written with the dataset datatype, so the int8 bytes are stored as is
+	}
+	return f
+}
+
+func TestReadEnumDataset(t *testing.T) {
+	f := enumFixture(t)
+
+	values, err := NewHdfDataset("/status", HdfReadOptions{File: f, ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer values.Close()
+	if got := *values.Data.Buffer.(*[]int8); !reflect.DeepEqual(got, []int8{0, -1, 2, 0}) {
+		t.Errorf("values: got %v", got)
+	}
+
+	names, err := NewHdfDataset("/status", HdfReadOptions{Dtype: reflect.String, File: f, ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer names.Close()
+	if got := *names.Data.Buffer.(*[]string); !reflect.DeepEqual(got, []string{"OK", "FAULT", "OFFLINE", "OK"}) {
+		t.Errorf("names: got %q", got)
+	}
+	row := []string{}
+	if err := names.ReadRow(1, &row); err != nil || !reflect.DeepEqual(row, []string{"OFFLINE", "OK"}) {
+		t.Errorf("row 1: got %q, %v", row, err)
+	}
+	if err := names.ReadSubset([]int{0, 1}, []int{1, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := *names.Data.Buffer.(*[]string); !reflect.DeepEqual(got, []string{"FAULT", "OK"}) {
+		t.Errorf("subset: got %q", got)
+	}
+
+	_, err = NewHdfDataset("/unmapped", HdfReadOptions{Dtype: reflect.String, File: f, ReadOnCreate: true})
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("read a value without a member as a name: got %v, want ErrOutOfRange", err)
+	}
+	unmapped, err := NewHdfDataset("/unmapped", HdfReadOptions{File: f, ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unmapped.Close()
+	if got := *unmapped.Data.Buffer.(*[]int8); !reflect.DeepEqual(got, []int8{0, 5}) {
+		t.Errorf("values without a member are read unchanged: got %v", got)
+	}
+}
+
+func TestDatasetEnum(t *testing.T) {
+	f := enumFixture(t)
+	checkEnum := func(source string, enum *HdfEnum) {
+		t.Helper()
+		if enum == nil {
+			t.Fatalf("%s: missing enum mapping", source)
+		}
+		if enum.Size != 1 || !enum.Signed || len(enum.Members) != 3 {
+			t.Errorf("%s: got %+v", source, enum)
+		}
+		for _, m := range []EnumMember{{"OK", 0}, {"FAULT", -1}, {"OFFLINE", 2}} {
+			if v, ok := enum.Value(m.Name); !ok || v != m.Value {
+				t.Errorf("%s: %s has value %d, %t", source, m.Name, v, ok)
+			}
+			if name, ok := enum.Name(m.Value); !ok || name != m.Name {
+				t.Errorf("%s: value %d has name %s, %t", source, m.Value, name, ok)
+			}
+		}
+	}
+
+	dset, err := NewHdfDataset("/status", HdfReadOptions{File: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	enum, err := dset.Enum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEnum("HdfDataset.Enum", enum)
+
+	attr, err := GetAttrMetadata(f, DatasetMetadata, "/status", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEnum("GetAttrMetadata", attr.Enum)
+	if attr.AttrSize != 1 {
+		t.Errorf("got attribute size %d, want 1", attr.AttrSize)
+	}
+
+	gauges := gaugeFixture(t)
+	member, err := GetAttrMetadata(gauges, CompoundMetadata, "/gauges", "Status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEnum("compound member", member.Enum)
+	name, err := GetAttrMetadata(gauges, CompoundMetadata, "/gauges", "Name")
+	if err != nil || name.Enum != nil {
+		t.Errorf("string member: got enum %+v, %v", name.Enum, err)
+	}
+}
@@ -0,0 +1,66 @@
+package hdf5utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error categories returned by the package. Test for them with errors.Is.
+var (
+	ErrFileNotFound         = errors.New("file not found")
+	ErrDatasetNotFound      = errors.New("dataset not found")
+	ErrAccessDenied         = errors.New("access denied")
+	ErrUnsupportedDatatype  = errors.New("unsupported datatype")
+	ErrOutOfRange           = errors.New("selection out of range")
+	ErrRemoteAccess         = errors.New("remote access failed")
+	ErrRemoteWrite          = errors.New("remote files are read-only")
+	ErrDatatypeMismatch     = errors.New("datatype does not match the dataset")
+	ErrLossyConversion      = errors.New("conversion may lose values")
+	ErrUnsupportedOperation = errors.New("operation not supported by the reader")
+)
+
+// HdfError records the operation and file or dataset path that failed.
+// Kind holds one of the Err* categories and Err the underlying cause.
+type HdfError struct {
+	Op   string
+	Path string
+	Kind error
+	Err  error
+}
+
+func (e *HdfError) Error() string {
+	msg := fmt.Sprintf("%s '%s'", e.Op, e.Path)
+	if e.Kind != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Kind)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *HdfError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+func (e *HdfError) Unwrap() error {
+	return e.Err
+}
+
+// errorKind returns the Err* category of err, nil for uncategorized errors
+func errorKind(err error) error {
+	for _, kind := range []error{
+		ErrFileNotFound, ErrDatasetNotFound, ErrAccessDenied, ErrUnsupportedDatatype,
+		ErrOutOfRange, ErrRemoteAccess, ErrRemoteWrite, ErrDatatypeMismatch, ErrLossyConversion,
+		ErrUnsupportedOperation,
+	} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
+
+func newHdfError(op string, path string, kind error, err error) error {
+	return &HdfError{Op: op, Path: path, Kind: kind, Err: err}
+}
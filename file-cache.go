@@ -0,0 +1,204 @@
+package hdf5utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+const cacheFileExt = ".h5cache"
+
+// FileCache stores local copies of remote HDF5 files keyed by url, ETag and size.
+// Files are evicted least recently used first once the cache exceeds MaxBytes.
+// Every fetch, including cache hits, requests the ETag and size of the remote object
+// with the file options credentials, so cached files cannot be opened offline.
+type FileCache struct {
+	Dir      string
+	MaxBytes int64 //0 disables eviction
+	mu       sync.Mutex
+	pending  map[string]*cacheDownload //downloads in progress keyed by cache path
+}
+
+// cacheDownload lets concurrent fetches of the same file wait for a single download
+type cacheDownload struct {
+	done chan struct{}
+	err  error
+}
+
+func NewFileCache(dir string, maxBytes int64) (*FileCache, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cache directory '%s': %s", dir, err)
+	}
+	return &FileCache{Dir: dir, MaxBytes: maxBytes}, nil
+}
+
+// Fetch returns the path of a local copy of the remote file, downloading it if the
+// cached copy is missing or the remote object has changed.  Concurrent fetches of the
+// same file share one download, fetches of other files are not blocked by it.  The
+// returned file can be evicted by later fetches, set HdfFileOptions.Cache to open
+// cached files safely.
+func (c *FileCache) Fetch(url string, options HdfFileOptions) (string, error) {
+	var path string
+	err := c.fetch(url, options, func(p string) error {
+		path = p
+		return nil
+	})
+	return path, err
+}
+
+// open fetches the file and opens the local copy before any other fetch can evict it.
+// Once HDF5 holds the file open an eviction only unlinks it.
+func (c *FileCache) open(url string, options HdfFileOptions) (*hdf5.File, error) {
+	var f *hdf5.File
+	err := c.fetch(url, options, func(path string) (err error) {
+		f, err = openLocalFile(path, hdf5.F_ACC_RDONLY)
+		return err
+	})
+	return f, err
+}
+
+// fetch downloads the file if needed and calls use with the cached path while holding c.mu
+func (c *FileCache) fetch(url string, options HdfFileOptions, use func(path string) error) error {
+	reader, err := c.remoteReader(url, options)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.Dir, cacheKey(url, reader.ETag(), reader.Size())+cacheFileExt)
+
+	for {
+		c.mu.Lock()
+		dl, ok := c.pending[path]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		<-dl.done
+		if dl.err != nil {
+			return dl.err
+		}
+		//check the download again under the lock, it may have been evicted since it finished
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() == reader.Size() {
+		defer c.mu.Unlock()
+		now := time.Now()
+		os.Chtimes(path, now, now) //modification time tracks last use
+		return use(path)
+	}
+	dl := &cacheDownload{done: make(chan struct{})}
+	if c.pending == nil {
+		c.pending = map[string]*cacheDownload{}
+	}
+	c.pending[path] = dl
+	c.mu.Unlock()
+
+	dl.err = c.download(reader, path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, path)
+	close(dl.done)
+	if dl.err != nil {
+		return dl.err
+	}
+	err = use(path)
+	c.evict(path)
+	return err
+}
+
+func (c *FileCache) remoteReader(url string, options HdfFileOptions) (*HttpReaderAt, error) {
+	if useHttpBackend(url, options) {
+		return NewHttpReaderAt(url, options.HttpAuth, options.HttpClient)
+	}
+	httpUrl, creds, region, err := resolveRemote(url, options)
+	if err != nil {
+		return nil, err
+	}
+	return newS3ReaderAt(httpUrl, creds, firstNonEmpty(region, DEFAULT_S3_REGION), options.HttpClient)
+}
+
+// download writes to a temporary file in the cache directory and renames it into place
+// so partially downloaded files are never served
+func (c *FileCache) download(reader *HttpReaderAt, path string) error {
+	tmp, err := os.CreateTemp(c.Dir, "download-*")
+	if err != nil {
+		return err
+	}
+	_, err = reader.WriteTo(tmp)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// evict removes the least recently used files until the cache fits in MaxBytes.
+// The file at keep is never removed.  Called with c.mu held.
+func (c *FileCache) evict(keep string) {
+	if c.MaxBytes <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return
+	}
+	var total int64
+	files := []os.FileInfo{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), cacheFileExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue //removed since the directory was read
+		}
+		total += info.Size()
+		files = append(files, info)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, f := range files {
+		if total <= c.MaxBytes {
+			return
+		}
+		path := filepath.Join(c.Dir, f.Name())
+		if path == keep {
+			continue
+		}
+		if os.Remove(path) == nil {
+			total -= f.Size()
+		}
+	}
+}
+
+// Clear removes all cached files
+func (c *FileCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	matches, err := filepath.Glob(filepath.Join(c.Dir, "*"+cacheFileExt))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		os.Remove(m)
+	}
+	return nil
+}
+
+func cacheKey(url string, etag string, size int64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", url, etag, size)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,166 @@
+package hdf5utils
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileCacheFetch(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 1000)
+	var gets int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			atomic.AddInt32(&gets, 1)
+		}
+		w.Header().Set("ETag", `"`+r.URL.Path+`"`)
+		http.ServeContent(w, r, "file.h5", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	cache, err := NewFileCache(t.TempDir(), 2500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := HdfFileOptions{Backend: BackendHttpDownload, HttpClient: srv.Client()}
+
+	var wg sync.WaitGroup
+	paths := make([]string, 4)
+	errs := make([]error, len(paths))
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], errs[i] = cache.Fetch(srv.URL+"/a.h5", options)
+		}(i)
+	}
+	wg.Wait()
+	for i, p := range paths {
+		if errs[i] != nil || p != paths[0] {
+			t.Fatalf("concurrent fetches returned paths %v, errors %v", paths, errs)
+		}
+	}
+	if n := atomic.LoadInt32(&gets); n != 1 {
+		t.Errorf("concurrent fetches of one file made %d downloads, want 1", n)
+	}
+	if b, err := os.ReadFile(paths[0]); err != nil || !bytes.Equal(b, content) {
+		t.Errorf("cached file does not match the remote file: %v", err)
+	}
+
+	//a hit does not download again
+	if _, err := cache.Fetch(srv.URL+"/a.h5", options); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&gets); n != 1 {
+		t.Errorf("cache hit downloaded the file, %d downloads", n)
+	}
+
+	//the third file exceeds MaxBytes and evicts the least recently used one
+	b, err := cache.Fetch(srv.URL+"/b.h5", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i, p := range []string{paths[0], b} {
+		used := now.Add(time.Duration(i-2) * time.Hour)
+		if err := os.Chtimes(p, used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := cache.Fetch(srv.URL+"/c.h5", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Errorf("least recently used file was not evicted")
+	}
+	for _, p := range []string{b, c} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("recent file %s was evicted", p)
+		}
+	}
+}
+
+func TestFileCacheDoesNotBlockOtherUrls(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.h5" && r.Method == "GET" {
+			<-release
+		}
+		http.ServeContent(w, r, "file.h5", time.Time{}, bytes.NewReader([]byte("content")))
+	}))
+	defer srv.Close()
+
+	cache, err := NewFileCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := HdfFileOptions{Backend: BackendHttpDownload, HttpClient: srv.Client()}
+	slow := make(chan struct{})
+	go func() {
+		cache.Fetch(srv.URL+"/slow.h5", options)
+		close(slow)
+	}()
+	defer func() {
+		close(release)
+		<-slow
+	}()
+
+	done := make(chan error)
+	go func() {
+		_, err := cache.Fetch(srv.URL+"/fast.h5", options)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetch of one file blocked behind the download of another")
+	}
+}
+
+func TestFileCacheOpenWhileEvicting(t *testing.T) {
+	values := []float64{1, 2, 3}
+	content := fixtureBytes(t, "/values", &values, []uint{3})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"`+r.URL.Path+`"`)
+		http.ServeContent(w, r, "file.h5", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	//every fetch of another url evicts all other files
+	cache, err := NewFileCache(t.TempDir(), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := HdfFileOptions{Backend: BackendHttpDownload, HttpClient: srv.Client(), Cache: cache}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := OpenFileWithOptions(fmt.Sprintf("%s/%d.h5", srv.URL, i%3), options)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			f.Close()
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("open %d: %v", i, err)
+		}
+	}
+}
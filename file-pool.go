@@ -0,0 +1,212 @@
+package hdf5utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// FilePool shares reference counted read-only file handles keyed by url and open options
+// so multiple readers of the same file only open it once.  Files with no remaining
+// references are closed after IdleTimeout, or immediately when IdleTimeout is zero.
+type FilePool struct {
+	IdleTimeout time.Duration
+	mu          sync.Mutex
+	files       map[string]*pooledFile
+	handles     map[*hdf5.File]*pooledFile
+	opening     map[string]*poolOpen //files being opened, keyed like files
+}
+
+// poolOpen lets concurrent acquires of a file wait for the caller opening it
+type poolOpen struct {
+	done chan struct{}
+	err  error
+}
+
+type pooledFile struct {
+	key   string
+	file  *hdf5.File
+	refs  int
+	timer *time.Timer
+}
+
+// DefaultFilePool is used by readers that do not set HdfReadOptions.Pool
+var DefaultFilePool = NewFilePool(0)
+
+func NewFilePool(idleTimeout time.Duration) *FilePool {
+	return &FilePool{
+		IdleTimeout: idleTimeout,
+		files:       map[string]*pooledFile{},
+		handles:     map[*hdf5.File]*pooledFile{},
+		opening:     map[string]*poolOpen{},
+	}
+}
+
+// Acquire returns a shared handle for the file, opening it if necessary.  The file is
+// opened without holding the pool lock, callers acquiring the same file wait for the
+// open to finish and callers acquiring other files are not blocked.
+// Each call must be matched with a call to Release.
+func (p *FilePool) Acquire(url string, options HdfFileOptions) (*hdf5.File, error) {
+	key := poolKey(url, options)
+	for {
+		p.mu.Lock()
+		if pf, ok := p.files[key]; ok {
+			if pf.timer != nil {
+				pf.timer.Stop()
+				pf.timer = nil
+			}
+			pf.refs++
+			p.mu.Unlock()
+			return pf.file, nil
+		}
+		op, ok := p.opening[key]
+		if !ok {
+			break //p.mu is still held
+		}
+		p.mu.Unlock()
+		<-op.done
+		if op.err != nil {
+			return nil, op.err
+		}
+	}
+
+	op := &poolOpen{done: make(chan struct{})}
+	p.opening[key] = op
+	p.mu.Unlock()
+
+	f, err := OpenFileWithOptions(url, options)
+
+	p.mu.Lock()
+	delete(p.opening, key)
+	if err == nil {
+		pf := &pooledFile{key: key, file: f, refs: 1}
+		p.files[key] = pf
+		p.handles[f] = pf
+	}
+	p.mu.Unlock()
+	op.err = err
+	close(op.done)
+	return f, err
+}
+
+// Reopen releases f and acquires a newly opened handle for the file.  Readers still
+// holding f keep using it until they release it, later calls to Acquire share the new handle.
+func (p *FilePool) Reopen(f *hdf5.File, url string, options HdfFileOptions) (*hdf5.File, error) {
+	p.detach(f)
+	p.Release(f)
+	return p.Acquire(url, options)
+}
+
+// detach stops sharing f so the next Acquire of its file opens a new handle.
+// f stays open until its remaining references are released.
+func (p *FilePool) detach(f *hdf5.File) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pf, ok := p.handles[f]; ok && p.files[pf.key] == pf {
+		delete(p.files, pf.key)
+	}
+}
+
+// Release drops a reference to a file returned by Acquire
+func (p *FilePool) Release(f *hdf5.File) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pf, ok := p.handles[f]
+	if !ok {
+		return
+	}
+	pf.refs--
+	if pf.refs > 0 {
+		return
+	}
+	if p.IdleTimeout <= 0 || p.files[pf.key] != pf {
+		//detached handles are closed as soon as they are unused
+		p.closeFile(pf)
+		return
+	}
+	pf.timer = time.AfterFunc(p.IdleTimeout, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.handles[pf.file]; ok && pf.refs == 0 {
+			p.closeFile(pf)
+		}
+	})
+}
+
+// Close closes every idle file in the pool.  Files still referenced remain open.
+func (p *FilePool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pf := range p.files {
+		if pf.refs == 0 {
+			if pf.timer != nil {
+				pf.timer.Stop()
+			}
+			p.closeFile(pf)
+		}
+	}
+}
+
+// Len returns the number of open files in the pool
+func (p *FilePool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.files)
+}
+
+func (p *FilePool) closeFile(pf *pooledFile) {
+	if p.files[pf.key] == pf {
+		delete(p.files, pf.key)
+	}
+	delete(p.handles, pf.file)
+	pf.file.Close()
+}
+
+// poolKey identifies a file by its url and every option that affects how it is opened.
+// Local paths are made absolute so every path to a file shares one handle.
+// Credentials are hashed so secrets are not kept in the key.
+func poolKey(url string, options HdfFileOptions) string {
+	if !isRemoteUrl(url) {
+		if abs, err := filepath.Abs(url); err == nil {
+			url = abs
+		}
+	}
+	parts := []string{
+		options.EnvPrefix,
+		options.Profile,
+		optionIdentity(options.Credentials),
+		options.S3Endpoint,
+		strconv.FormatBool(options.S3PathStyle),
+		strconv.Itoa(int(options.Backend)),
+		fmt.Sprintf("%#v", options.HttpAuth),
+		optionIdentity(options.HttpClient),
+		optionIdentity(options.Cache),
+		strconv.FormatBool(options.SWMR),
+		optionIdentity(options.Retry),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return url + "|" + hex.EncodeToString(sum[:])
+}
+
+// optionIdentity describes an option value, pointers by address so options sharing a
+// client or cache share files
+func optionIdentity(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return fmt.Sprintf("%T@%x", v, rv.Pointer())
+	}
+	return fmt.Sprintf("%#v", v)
+}
@@ -0,0 +1,112 @@
+package hdf5utils
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+func TestPoolKey(t *testing.T) {
+	url := "s3://bucket/file.h5"
+	base := HdfFileOptions{Profile: "dev"}
+	if poolKey(url, base) != poolKey(url, HdfFileOptions{Profile: "dev"}) {
+		t.Error("equal options must share a key")
+	}
+	static := func(key string) CredentialProvider {
+		return StaticCredentialProvider{Credentials{AccessKeyID: key, SecretAccessKey: "secret"}}
+	}
+	if poolKey(url, HdfFileOptions{Credentials: static("a")}) != poolKey(url, HdfFileOptions{Credentials: static("a")}) {
+		t.Error("equal credential values must share a key")
+	}
+
+	client := &http.Client{}
+	cache := &FileCache{Dir: "cache"}
+	differing := []HdfFileOptions{
+		{Profile: "prod"},
+		{Profile: "dev", EnvPrefix: "DEV"},
+		{Profile: "dev", Credentials: static("a")},
+		{Profile: "dev", Credentials: static("b")},
+		{Profile: "dev", S3Endpoint: "http://localhost:9000"},
+		{Profile: "dev", S3PathStyle: true},
+		{Profile: "dev", Backend: BackendHttpDownload},
+		{Profile: "dev", HttpAuth: HttpAuth{BearerToken: "token"}},
+		{Profile: "dev", HttpAuth: HttpAuth{Headers: map[string]string{"X-Api-Key": "key"}}},
+		{Profile: "dev", HttpClient: client},
+		{Profile: "dev", Cache: cache},
+		{Profile: "dev", SWMR: true},
+		{Profile: "dev", Retry: DefaultRetryPolicy()},
+	}
+	keys := map[string]int{poolKey(url, base): -1}
+	for i, o := range differing {
+		k := poolKey(url, o)
+		if j, ok := keys[k]; ok {
+			t.Errorf("options %d and %d share a key", i, j)
+		}
+		keys[k] = i
+	}
+	if poolKey(url, HdfFileOptions{HttpClient: client}) != poolKey(url, HdfFileOptions{HttpClient: client}) {
+		t.Error("options sharing a client must share a key")
+	}
+	if k := poolKey(url, HdfFileOptions{Credentials: static("a")}); len(k) == 0 || strings.Contains(k, "secret") {
+		t.Error("pool keys must not contain secrets")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := poolKey(filepath.Join(wd, "data", "a.h5"), base)
+	for _, path := range []string{"data/a.h5", "./data/a.h5", "data//b/../a.h5"} {
+		if poolKey(path, base) != local {
+			t.Errorf("%s and its absolute path must share a key", path)
+		}
+	}
+	if poolKey("data/b.h5", base) == local {
+		t.Error("different local files must not share a key")
+	}
+}
+
+func TestFilePoolAcquire(t *testing.T) {
+	values := []float64{1, 2, 3}
+	path := fixtureFile(t, "/values", &values, []uint{3})
+	pool := NewFilePool(0)
+
+	var wg sync.WaitGroup
+	files := make([]*hdf5.File, 8)
+	for i := range files {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := pool.Acquire(path, HdfFileOptions{})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			files[i] = f
+		}(i)
+	}
+	wg.Wait()
+	for _, f := range files {
+		if f != files[0] {
+			t.Fatal("concurrent acquires of one file returned different handles")
+		}
+	}
+	if pool.Len() != 1 {
+		t.Errorf("got %d open files, want 1", pool.Len())
+	}
+	for _, f := range files {
+		pool.Release(f)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("got %d open files after release, want 0", pool.Len())
+	}
+
+	if _, err := pool.Acquire(path+".missing", HdfFileOptions{}); err == nil {
+		t.Error("expected an error acquiring a missing file")
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/usace/hdf5utils
 
-go 1.15
+go 1.18
 
 //replace github.com/usace/go-hdf5 => /workspaces/go-hdf5
 
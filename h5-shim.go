@@ -0,0 +1,623 @@
+package hdf5utils
+
+// Thin cgo wrappers for HDF5 calls that github.com/usace/go-hdf5 does not expose.
+// Objects are passed by their hid_t value (Identifier.ID()) so the wrappers can be
+// used with handles created through go-hdf5.
+
+// #cgo LDFLAGS: -lhdf5 -lhdf5_hl
+// #cgo darwin CFLAGS: -I/usr/local/include
+// #cgo darwin LDFLAGS: -L/usr/local/lib
+// #cgo linux,!arm64 CFLAGS: -I/usr/local/include, -I/usr/lib/x86_64-linux-gnu/hdf5/serial/include
+// #cgo linux,!arm64 LDFLAGS: -L/usr/local/lib, -L/usr/lib/x86_64-linux-gnu/hdf5/serial/
+// #cgo linux,arm64 CFLAGS: -I/usr/local/include, -I/usr/lib/aarch64-linux-gnu/hdf5/serial/include
+// #cgo linux,arm64 LDFLAGS: -L/usr/local/lib, -L/usr/lib/aarch64-linux-gnu/hdf5/serial/
+// #include "hdf5.h"
+// #include <stdlib.h>
+// #include <string.h>
+//
+// static herr_t _hdf5utils_set_fapl_ros3_token(hid_t fapl_id, const char *token) {
+// #if H5_VERSION_GE(1,14,2)
+//   return H5Pset_fapl_ros3_token(fapl_id, token);
+// #else
+//   return -1;
+// #endif
+// }
+//
+// static hsize_t _hdf5utils_unlimited() { return H5S_UNLIMITED; }
+//
+// static unsigned _hdf5utils_swmr_read() {
+// #ifdef H5F_ACC_SWMR_READ
+//   return H5F_ACC_SWMR_READ;
+// #else
+//   return 0;
+// #endif
+// }
+//
+// static unsigned _hdf5utils_swmr_write() {
+// #ifdef H5F_ACC_SWMR_WRITE
+//   return H5F_ACC_SWMR_WRITE;
+// #else
+//   return 0;
+// #endif
+// }
+//
+// static herr_t _hdf5utils_set_libver_latest(hid_t fapl_id) {
+//   return H5Pset_libver_bounds(fapl_id, H5F_LIBVER_LATEST, H5F_LIBVER_LATEST);
+// }
+//
+// static herr_t _hdf5utils_create_latest_format(const char *name) {
+//   hid_t fapl = H5Pcreate(H5P_FILE_ACCESS);
+//   if (fapl < 0) {
+//     return -1;
+//   }
+//   hid_t f = -1;
+//   if (_hdf5utils_set_libver_latest(fapl) >= 0) {
+//     f = H5Fcreate(name, H5F_ACC_TRUNC, H5P_DEFAULT, fapl);
+//   }
+//   H5Pclose(fapl);
+//   if (f < 0) {
+//     return -1;
+//   }
+//   return H5Fclose(f);
+// }
+//
+// static herr_t _hdf5utils_refresh_dataset(hid_t dset_id) {
+// #if H5_VERSION_GE(1,10,0)
+//   return H5Drefresh(dset_id);
+// #else
+//   return -1;
+// #endif
+// }
+//
+// static herr_t _hdf5utils_set_fapl_core_image(hid_t fapl_id, void *buf, size_t len) {
+//   if (H5Pset_fapl_core(fapl_id, 1024 * 1024, 0) < 0) {
+//     return -1;
+//   }
+//   return H5Pset_file_image(fapl_id, buf, len);
+// }
+//
+// static hid_t _hdf5utils_vlen_string_type(hid_t ftype) {
+//   hid_t mtype = H5Tcopy(H5T_C_S1);
+//   H5Tset_size(mtype, H5T_VARIABLE);
+//   H5Tset_cset(mtype, H5Tget_cset(ftype));
+//   return mtype;
+// }
+//
+// static hid_t _hdf5utils_dataset_vlen_string_type(hid_t dset_id) {
+//   hid_t ftype = H5Dget_type(dset_id);
+//   if (ftype < 0) {
+//     return -1;
+//   }
+//   hid_t mtype = _hdf5utils_vlen_string_type(ftype);
+//   H5Tclose(ftype);
+//   return mtype;
+// }
+//
+// static herr_t _hdf5utils_read_vlen_strings(hid_t dset_id, hid_t mtype, hid_t mem_space, hid_t file_space, char **buf) {
+//   if (mem_space == 0) {
+//     return H5Dread(dset_id, mtype, H5S_ALL, H5S_ALL, H5P_DEFAULT, buf);
+//   }
+//   return H5Dread(dset_id, mtype, mem_space, file_space, H5P_DEFAULT, buf);
+// }
+//
+// static herr_t _hdf5utils_write_vlen_strings(hid_t dset_id, hid_t mtype, char **buf) {
+//   return H5Dwrite(dset_id, mtype, H5S_ALL, H5S_ALL, H5P_DEFAULT, buf);
+// }
+//
+// static herr_t _hdf5utils_reclaim_vlen(hid_t dset_id, hid_t mtype, hid_t mem_space, void *buf) {
+//   hid_t space = mem_space;
+//   if (mem_space == 0) {
+//     space = H5Dget_space(dset_id);
+//   }
+// #if H5_VERSION_GE(1,12,0)
+//   herr_t status = H5Treclaim(mtype, space, H5P_DEFAULT, buf);
+// #else
+//   herr_t status = H5Dvlen_reclaim(mtype, space, H5P_DEFAULT, buf);
+// #endif
+//   if (mem_space == 0) {
+//     H5Sclose(space);
+//   }
+//   return status;
+// }
+//
+// static herr_t _hdf5utils_enum_insert(hid_t type_id, const char *name, long long value) {
+//   unsigned char buf[16];
+//   memset(buf, 0, sizeof(buf));
+//   memcpy(buf, &value, sizeof(value));
+//   hid_t super = H5Tget_super(type_id);
+//   if (super < 0) {
+//     return -1;
+//   }
+//   herr_t status = H5Tconvert(H5T_NATIVE_LLONG, super, 1, buf, NULL, H5P_DEFAULT);
+//   H5Tclose(super);
+//   if (status < 0) {
+//     return -1;
+//   }
+//   return H5Tenum_insert(type_id, name, buf);
+// }
+//
+// static H5T_sign_t _hdf5utils_get_sign(hid_t type_id) {
+//   if (H5Tget_class(type_id) != H5T_ENUM) {
+//     return H5Tget_sign(type_id);
+//   }
+//   hid_t super = H5Tget_super(type_id);
+//   if (super < 0) {
+//     return H5T_SGN_ERROR;
+//   }
+//   H5T_sign_t sign = H5Tget_sign(super);
+//   H5Tclose(super);
+//   return sign;
+// }
+//
+// static H5T_order_t _hdf5utils_get_order(hid_t type_id) {
+//   H5T_class_t cls = H5Tget_class(type_id);
+//   if (cls != H5T_ENUM && cls != H5T_ARRAY) {
+//     return H5Tget_order(type_id);
+//   }
+//   hid_t super = H5Tget_super(type_id);
+//   if (super < 0) {
+//     return H5T_ORDER_ERROR;
+//   }
+//   H5T_order_t order = H5Tget_order(super);
+//   H5Tclose(super);
+//   return order;
+// }
+//
+// static herr_t _hdf5utils_enum_value(hid_t type_id, unsigned idx, long long *value) {
+//   unsigned char buf[16];
+//   memset(buf, 0, sizeof(buf));
+//   if (H5Tget_member_value(type_id, idx, buf) < 0) {
+//     return -1;
+//   }
+//   hid_t super = H5Tget_super(type_id);
+//   if (super < 0) {
+//     return -1;
+//   }
+//   herr_t status = H5Tconvert(super, H5T_NATIVE_LLONG, 1, buf, NULL, H5P_DEFAULT);
+//   H5Tclose(super);
+//   if (status < 0) {
+//     return -1;
+//   }
+//   memcpy(value, buf, sizeof(*value));
+//   return 0;
+// }
+//
+// static herr_t _hdf5utils_array_base(hid_t type_id, int *cls, size_t *size, int *sign) {
+//   hid_t super = H5Tget_super(type_id);
+//   if (super < 0) {
+//     return -1;
+//   }
+//   *cls = H5Tget_class(super);
+//   *size = H5Tget_size(super);
+//   *sign = *cls == H5T_INTEGER ? H5Tget_sign(super) : H5T_SGN_NONE;
+//   H5Tclose(super);
+//   return (*cls < 0 || *size == 0 || *sign < 0) ? -1 : 0;
+// }
+//
+// static herr_t _hdf5utils_io_walk(unsigned n, const H5E_error2_t *err, void *data) {
+//   if (err->maj_num == H5E_IO || err->maj_num == H5E_VFL) {
+//     *(int *)data = 1;
+//   }
+//   return 0;
+// }
+//
+// // reports whether the current error stack holds a low-level I/O or file driver error.
+// // Must be called before any other HDF5 API call clears the stack.
+// static int _hdf5utils_io_failure() {
+//   int io = 0;
+//   H5Ewalk2(H5E_DEFAULT, H5E_WALK_UPWARD, _hdf5utils_io_walk, &io);
+//   return io;
+// }
+//
+// static herr_t _hdf5utils_reclaim_attr_vlen(hid_t attr_id, hid_t mtype, void *buf) {
+//   hid_t space = H5Aget_space(attr_id);
+// #if H5_VERSION_GE(1,12,0)
+//   herr_t status = H5Treclaim(mtype, space, H5P_DEFAULT, buf);
+// #else
+//   herr_t status = H5Dvlen_reclaim(mtype, space, H5P_DEFAULT, buf);
+// #endif
+//   H5Sclose(space);
+//   return status;
+// }
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// setFaplRos3Token adds an AWS session token to a ROS3 file access property list.
+// Requires HDF5 1.14.2 or newer.
+func setFaplRos3Token(faplID int64, token string) error {
+	ctoken := C.CString(token)
+	defer C.free(unsafe.Pointer(ctoken))
+	if C._hdf5utils_set_fapl_ros3_token(C.hid_t(faplID), ctoken) < 0 {
+		return errors.New("unable to set the ROS3 session token (requires HDF5 1.14.2 or newer)")
+	}
+	return nil
+}
+
+// unlimitedDim is the maximum dimension size of an extendable dataset
+var unlimitedDim = uint(C._hdf5utils_unlimited())
+
+// setExtent changes the current dimensions of a chunked dataset
+func setExtent(dsetID int64, dims []uint) error {
+	if len(dims) == 0 {
+		return errors.New("unable to set the extent of a scalar dataset")
+	}
+	cdims := make([]C.hsize_t, len(dims))
+	for i, d := range dims {
+		cdims[i] = C.hsize_t(d)
+	}
+	if C.H5Dset_extent(C.hid_t(dsetID), &cdims[0]) < 0 {
+		return fmt.Errorf("unable to extend dataset to %v", dims)
+	}
+	return nil
+}
+
+func setShuffle(dcplID int64) error {
+	if C.H5Pset_shuffle(C.hid_t(dcplID)) < 0 {
+		return errors.New("unable to set the shuffle filter")
+	}
+	return nil
+}
+
+func setFletcher32(dcplID int64) error {
+	if C.H5Pset_fletcher32(C.hid_t(dcplID)) < 0 {
+		return errors.New("unable to set the fletcher32 filter")
+	}
+	return nil
+}
+
+// datasetFilters returns the filter ids of a dataset creation property list in pipeline order
+func datasetFilters(dcplID int64) ([]int, error) {
+	n := int(C.H5Pget_nfilters(C.hid_t(dcplID)))
+	if n < 0 {
+		return nil, errors.New("unable to get the number of filters")
+	}
+	ids := make([]int, n)
+	for i := range ids {
+		var flags, config C.uint
+		var nelmts C.size_t
+		id := C.H5Pget_filter2(C.hid_t(dcplID), C.uint(i), &flags, &nelmts, nil, 0, nil, &config)
+		if id < 0 {
+			return nil, fmt.Errorf("unable to get filter %d", i)
+		}
+		ids[i] = int(id)
+	}
+	return ids, nil
+}
+
+// setFillValue sets the fill value of a dataset creation property list.
+// value must be a pointer to a single value of the memory datatype typeID.
+func setFillValue(dcplID int64, typeID int64, value interface{}) error {
+	ptr := unsafe.Pointer(reflect.ValueOf(value).Pointer())
+	if C.H5Pset_fill_value(C.hid_t(dcplID), C.hid_t(typeID), ptr) < 0 {
+		return errors.New("unable to set the fill value")
+	}
+	return nil
+}
+
+// setFaplCoreImage configures a file access property list to open an in-memory file image
+// using the core driver without a backing store.  HDF5 copies the image.
+func setFaplCoreImage(faplID int64, image []byte) error {
+	if len(image) == 0 {
+		return errors.New("empty file image")
+	}
+	if C._hdf5utils_set_fapl_core_image(C.hid_t(faplID), unsafe.Pointer(&image[0]), C.size_t(len(image))) < 0 {
+		return errors.New("unable to set the file image")
+	}
+	return nil
+}
+
+// swmrReadFlag opens a file for single-writer/multiple-reader access.  The value is
+// taken from the HDF5 headers and is 0 when the library was built without SWMR support.
+var swmrReadFlag = int(C._hdf5utils_swmr_read())
+
+// swmrWriteFlag opens a file in the latest format as the writer of SWMR readers, 0 without SWMR support
+var swmrWriteFlag = int(C._hdf5utils_swmr_write())
+
+// createLatestFormatFile creates an empty file in the latest file format, which SWMR writing requires
+func createLatestFormatFile(path string) error {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	if C._hdf5utils_create_latest_format(cpath) < 0 {
+		return fmt.Errorf("unable to create file '%s'", path)
+	}
+	return nil
+}
+
+// openLatestFormatFile opens a file so new objects use the latest file format
+func openLatestFormatFile(path string, flags int) (*hdf5.File, error) {
+	fapl, err := hdf5.NewPropList(hdf5.P_FILE_ACCESS)
+	if err != nil {
+		return nil, err
+	}
+	defer fapl.Close()
+	if C._hdf5utils_set_libver_latest(C.hid_t(fapl.ID())) < 0 {
+		return nil, errors.New("unable to set the file format version bounds")
+	}
+	return hdf5.OpenFileWithProp(path, flags, fapl)
+}
+
+// errNoSWMR is returned when SWMR reading is requested from a library without SWMR support
+var errNoSWMR = errors.New("SWMR reading requires an HDF5 library built with SWMR support (1.10 or newer)")
+
+// fileIntent returns the access flags a file was opened with
+func fileIntent(fileID int64) (int, error) {
+	var intent C.uint
+	if C.H5Fget_intent(C.hid_t(fileID), &intent) < 0 {
+		return 0, errors.New("unable to get the file intent")
+	}
+	return int(intent), nil
+}
+
+// refreshDataset reloads the metadata of a dataset opened for SWMR reading
+func refreshDataset(dsetID int64) error {
+	if C._hdf5utils_refresh_dataset(C.hid_t(dsetID)) < 0 {
+		return errors.New("unable to refresh dataset")
+	}
+	return nil
+}
+
+// errIOFailure marks reads that failed in the HDF5 I/O or file driver layers, such as
+// a dropped connection to a remote file, rather than in datatype conversion or selection
+var errIOFailure = errors.New("HDF5 I/O failure")
+
+// readFailure returns an error for a failed read, wrapping errIOFailure when the HDF5
+// error stack holds an I/O error.  It must be called directly after the failed call.
+func readFailure(msg string) error {
+	if C._hdf5utils_io_failure() != 0 {
+		return fmt.Errorf("%s: %w", msg, errIOFailure)
+	}
+	return errors.New(msg)
+}
+
+// isVarLenString reports whether typeID is a variable length string datatype
+func isVarLenString(typeID int64) bool {
+	return C.H5Tis_variable_str(C.hid_t(typeID)) > 0
+}
+
+// readVarLenStrings reads n variable length strings from a dataset.  memspaceID and
+// filespaceID select the elements to read, 0 reads the entire dataset.
+// The strings allocated by HDF5 are copied and reclaimed before returning.
+func readVarLenStrings(dsetID int64, memspaceID int64, filespaceID int64, n int) ([]string, error) {
+	if n == 0 {
+		return []string{}, nil
+	}
+	mtype := C._hdf5utils_dataset_vlen_string_type(C.hid_t(dsetID))
+	if mtype < 0 {
+		return nil, errors.New("unable to create the variable length string memory type")
+	}
+	defer C.H5Tclose(mtype)
+
+	buf := C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(uintptr(0))))
+	if buf == nil {
+		return nil, errors.New("unable to allocate the variable length string buffer")
+	}
+	defer C.free(buf)
+
+	if C._hdf5utils_read_vlen_strings(C.hid_t(dsetID), mtype, C.hid_t(memspaceID), C.hid_t(filespaceID), (**C.char)(buf)) < 0 {
+		err := readFailure("unable to read variable length strings")
+		//a failed read may leave some strings allocated, unread elements are null
+		C._hdf5utils_reclaim_vlen(C.hid_t(dsetID), mtype, C.hid_t(memspaceID), buf)
+		return nil, err
+	}
+	strs := goStrings(buf, n)
+	if C._hdf5utils_reclaim_vlen(C.hid_t(dsetID), mtype, C.hid_t(memspaceID), buf) < 0 {
+		return nil, errors.New("unable to reclaim variable length strings")
+	}
+	return strs, nil
+}
+
+// attributeExists reports whether the object objID has an attribute named name
+func attributeExists(objID int64, name string) bool {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return C.H5Aexists(C.hid_t(objID), cname) > 0
+}
+
+// writeVarLenStrings writes strs to every element of a variable length string dataset
+func writeVarLenStrings(dsetID int64, strs []string) error {
+	if len(strs) == 0 {
+		return nil
+	}
+	mtype := C._hdf5utils_dataset_vlen_string_type(C.hid_t(dsetID))
+	if mtype < 0 {
+		return errors.New("unable to create the variable length string memory type")
+	}
+	defer C.H5Tclose(mtype)
+
+	buf := C.calloc(C.size_t(len(strs)), C.size_t(unsafe.Sizeof(uintptr(0))))
+	if buf == nil {
+		return errors.New("unable to allocate the variable length string buffer")
+	}
+	defer C.free(buf)
+	ptrs := (*[1 << 30]*C.char)(buf)[:len(strs):len(strs)]
+	for i, str := range strs {
+		ptrs[i] = C.CString(str)
+	}
+	defer func() {
+		for _, p := range ptrs {
+			C.free(unsafe.Pointer(p))
+		}
+	}()
+
+	if C._hdf5utils_write_vlen_strings(C.hid_t(dsetID), mtype, (**C.char)(buf)) < 0 {
+		return errors.New("unable to write variable length strings")
+	}
+	return nil
+}
+
+// stringFormat returns the padding and character set of a fixed length string datatype
+func stringFormat(typeID int64) (StringFormat, error) {
+	pad := C.H5Tget_strpad(C.hid_t(typeID))
+	if pad < 0 {
+		return StringFormat{}, errors.New("unable to get the string padding")
+	}
+	cset := C.H5Tget_cset(C.hid_t(typeID))
+	if cset < 0 {
+		return StringFormat{}, errors.New("unable to get the string character set")
+	}
+	return StringFormat{Pad: StrPad(pad), Charset: StrCharset(cset)}, nil
+}
+
+// readAttrStrings reads a fixed or variable length string attribute as a list of strings
+// decoded according to the padding and character set of the attribute datatype.
+func readAttrStrings(attrID int64) ([]string, error) {
+	ftype := C.H5Aget_type(C.hid_t(attrID))
+	if ftype < 0 {
+		return nil, errors.New("unable to get the attribute datatype")
+	}
+	defer C.H5Tclose(ftype)
+	if C.H5Tget_class(ftype) != C.H5T_STRING {
+		return nil, fmt.Errorf("attribute is not a string: %w", ErrUnsupportedDatatype)
+	}
+
+	space := C.H5Aget_space(C.hid_t(attrID))
+	if space < 0 {
+		return nil, errors.New("unable to get the attribute dataspace")
+	}
+	n := int(C.H5Sget_simple_extent_npoints(space))
+	C.H5Sclose(space)
+	if n <= 0 {
+		return []string{}, nil
+	}
+	format, err := stringFormat(int64(ftype))
+	if err != nil {
+		return nil, err
+	}
+
+	if C.H5Tis_variable_str(ftype) > 0 {
+		mtype := C._hdf5utils_vlen_string_type(ftype)
+		if mtype < 0 {
+			return nil, errors.New("unable to create the variable length string memory type")
+		}
+		defer C.H5Tclose(mtype)
+		buf := C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(uintptr(0))))
+		if buf == nil {
+			return nil, errors.New("unable to allocate the variable length string buffer")
+		}
+		defer C.free(buf)
+		if C.H5Aread(C.hid_t(attrID), mtype, buf) < 0 {
+			C._hdf5utils_reclaim_attr_vlen(C.hid_t(attrID), mtype, buf)
+			return nil, errors.New("unable to read the attribute")
+		}
+		strs := goStrings(buf, n)
+		if C._hdf5utils_reclaim_attr_vlen(C.hid_t(attrID), mtype, buf) < 0 {
+			return nil, errors.New("unable to reclaim variable length strings")
+		}
+		for i, str := range strs {
+			strs[i] = format.Decode([]byte(str))
+		}
+		return strs, nil
+	}
+
+	size := int(C.H5Tget_size(ftype))
+	raw := make([]byte, n*size)
+	if C.H5Aread(C.hid_t(attrID), ftype, unsafe.Pointer(&raw[0])) < 0 {
+		return nil, errors.New("unable to read the attribute")
+	}
+	strs := make([]string, n)
+	for i := range strs {
+		strs[i] = format.Decode(raw[i*size : (i+1)*size])
+	}
+	return strs, nil
+}
+
+// goStrings copies n C strings from an array of char pointers, nil pointers become empty strings
+func goStrings(buf unsafe.Pointer, n int) []string {
+	ptrs := (*[1 << 30]*C.char)(buf)[:n:n]
+	strs := make([]string, n)
+	for i, p := range ptrs {
+		if p != nil {
+			strs[i] = C.GoString(p)
+		}
+	}
+	return strs
+}
+
+// enumInsert adds a member to an enum datatype, converting value to the enum base type
+func enumInsert(typeID int64, name string, value int64) error {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	if C._hdf5utils_enum_insert(C.hid_t(typeID), cname, C.longlong(value)) < 0 {
+		return fmt.Errorf("unable to add enum member %s=%d", name, value)
+	}
+	return nil
+}
+
+// typeSigned reports whether an integer datatype, or the base type of an enum, is signed
+func typeSigned(typeID int64) (bool, error) {
+	sign := C._hdf5utils_get_sign(C.hid_t(typeID))
+	if sign < 0 {
+		return false, errors.New("unable to get the integer sign")
+	}
+	return sign != C.H5T_SGN_NONE, nil
+}
+
+// typeBigEndian reports whether a numeric datatype, or the base type of an enum or array, is stored big endian
+func typeBigEndian(typeID int64) (bool, error) {
+	order := C._hdf5utils_get_order(C.hid_t(typeID))
+	if order < 0 {
+		return false, errors.New("unable to get the byte order")
+	}
+	return order == C.H5T_ORDER_BE, nil
+}
+
+// readDataset reads the selection of a dataset into data, a pointer to a slice, converting
+// the elements to the memory datatype memTypeID.  memspaceID and filespaceID select the
+// elements to read, 0 reads the entire dataset.  The slice must hold n elements.
+func readDataset(dsetID int64, memTypeID int64, memspaceID int64, filespaceID int64, data interface{}, n int) error {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("read expects a pointer to a slice, not %T", data)
+	}
+	if v.Len() < n {
+		return fmt.Errorf("buffer of %d elements is too small for %d values", v.Len(), n)
+	}
+	if n == 0 {
+		return nil
+	}
+	if C.H5Dread(C.hid_t(dsetID), C.hid_t(memTypeID), C.hid_t(memspaceID), C.hid_t(filespaceID), C.H5P_DEFAULT, unsafe.Pointer(v.Pointer())) < 0 {
+		return readFailure("unable to read dataset")
+	}
+	return nil
+}
+
+// enumMembers returns the names and values of the members of an enum datatype
+func enumMembers(typeID int64) ([]EnumMember, error) {
+	n := int(C.H5Tget_nmembers(C.hid_t(typeID)))
+	if n < 0 {
+		return nil, errors.New("unable to get the enum members")
+	}
+	members := make([]EnumMember, n)
+	for i := range members {
+		cname := C.H5Tget_member_name(C.hid_t(typeID), C.uint(i))
+		if cname == nil {
+			return nil, fmt.Errorf("unable to get the name of enum member %d", i)
+		}
+		members[i].Name = C.GoString(cname)
+		C.H5free_memory(unsafe.Pointer(cname))
+		var value C.longlong
+		if C._hdf5utils_enum_value(C.hid_t(typeID), C.uint(i), &value) < 0 {
+			return nil, fmt.Errorf("unable to get the value of enum member %s", members[i].Name)
+		}
+		members[i].Value = int64(value)
+	}
+	return members, nil
+}
+
+// arrayBase returns the class, byte size and sign of the element type of an array datatype
+func arrayBase(typeID int64) (hdf5.TypeClass, uint, bool, error) {
+	var cls, sign C.int
+	var size C.size_t
+	if C._hdf5utils_array_base(C.hid_t(typeID), &cls, &size, &sign) < 0 {
+		return hdf5.T_NO_CLASS, 0, false, errors.New("unable to get the array element type")
+	}
+	return hdf5.TypeClass(cls), uint(size), sign != C.H5T_SGN_NONE, nil
+}
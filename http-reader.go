@@ -0,0 +1,239 @@
+package hdf5utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// size of each ranged GET used when fetching a file
+const httpBlockSize = 16 * 1024 * 1024
+
+type HdfBackend int
+
+const (
+	BackendAuto         HdfBackend = iota //http download for http(s) urls with HttpAuth set, since ROS3 cannot send it, otherwise ROS3
+	BackendRos3                           //HDF5 read-only S3 driver, reads byte ranges on demand, signed with SigV4 or anonymous
+	BackendHttpDownload                   //full download of the file to local disk before it is opened, with optional bearer or basic auth
+)
+
+// HttpAuth holds optional authentication for the http download backend
+type HttpAuth struct {
+	BearerToken string
+	Username    string //basic auth
+	Password    string
+	Headers     map[string]string //additional request headers
+}
+
+func (a HttpAuth) isSet() bool {
+	return a.BearerToken != "" || a.Username != "" || len(a.Headers) > 0
+}
+
+func (a HttpAuth) apply(req *http.Request) {
+	for k, v := range a.Headers {
+		req.Header.Set(k, v)
+	}
+	if a.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.BearerToken)
+	} else if a.Username != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+}
+
+// HttpReaderAt implements io.ReaderAt over HTTP range requests
+type HttpReaderAt struct {
+	url       string
+	authorize func(req *http.Request)
+	client    *http.Client
+	size      int64
+	etag      string
+}
+
+// NewHttpReaderAt determines the size of the remote object and returns a reader for it.
+// A nil client uses http.DefaultClient.
+func NewHttpReaderAt(url string, auth HttpAuth, client *http.Client) (*HttpReaderAt, error) {
+	return newHttpReaderAt(url, auth.apply, client)
+}
+
+// newS3ReaderAt returns a reader for an s3 object url, signing requests unless the credentials are anonymous
+func newS3ReaderAt(url string, creds Credentials, region string, client *http.Client) (*HttpReaderAt, error) {
+	return newHttpReaderAt(url, func(req *http.Request) {
+		if !creds.Anonymous {
+			signV4(req, creds, region, time.Now())
+		}
+	}, client)
+}
+
+func newHttpReaderAt(url string, authorize func(req *http.Request), client *http.Client) (*HttpReaderAt, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &HttpReaderAt{url: url, authorize: authorize, client: client}
+	size, err := r.contentLength()
+	if err != nil {
+		return nil, err
+	}
+	r.size = size
+	return r, nil
+}
+
+func (r *HttpReaderAt) Size() int64 {
+	return r.size
+}
+
+// ETag returns the entity tag reported by the server, if any
+func (r *HttpReaderAt) ETag() string {
+	return r.etag
+}
+
+func (r *HttpReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	end := off + int64(len(p)) - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+	resp, err := r.do("GET", fmt.Sprintf("bytes=%d-%d", off, end))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent && !(resp.StatusCode == http.StatusOK && off == 0) {
+		return 0, newHdfError("read range", r.url, ErrRemoteAccess, fmt.Errorf("server does not support range requests (status %d)", resp.StatusCode))
+	}
+	want := int(end - off + 1)
+	n, err := io.ReadFull(resp.Body, p[:want])
+	if err != nil {
+		return n, newHdfError("read range", r.url, ErrRemoteAccess, err)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// contentLength uses a HEAD request, falling back to a one byte range request
+// for servers that do not report the length on HEAD
+func (r *HttpReaderAt) contentLength() (int64, error) {
+	resp, err := r.do("HEAD", "")
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	r.etag = resp.Header.Get("ETag")
+	if resp.ContentLength > 0 {
+		return resp.ContentLength, nil
+	}
+
+	resp, err = r.do("GET", "bytes=0-0")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	//Content-Range: bytes 0-0/12345
+	cr := resp.Header.Get("Content-Range")
+	if i := strings.LastIndex(cr, "/"); i >= 0 {
+		size, err := strconv.ParseInt(cr[i+1:], 10, 64)
+		if err == nil {
+			return size, nil
+		}
+	}
+	return 0, newHdfError("open file", r.url, ErrRemoteAccess, fmt.Errorf("unable to determine the size of the remote file"))
+}
+
+func (r *HttpReaderAt) do(method string, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequest(method, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	r.authorize(req)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, newHdfError("open file", r.url, ErrRemoteAccess, err)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, httpStatusError(r.url, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func httpStatusError(url string, status int) error {
+	err := fmt.Errorf("http status %d", status)
+	switch status {
+	case http.StatusNotFound:
+		return newHdfError("open file", url, ErrFileNotFound, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return newHdfError("open file", url, ErrAccessDenied, err)
+	default:
+		return newHdfError("open file", url, ErrRemoteAccess, err)
+	}
+}
+
+// useHttpBackend reports whether an http(s) url is opened with the http download backend
+// rather than ROS3.  s3:// urls always use ROS3.
+func useHttpBackend(url string, options HdfFileOptions) bool {
+	if !isHttpUrl(url) {
+		return false
+	}
+	switch options.Backend {
+	case BackendHttpDownload:
+		return true
+	case BackendAuto:
+		return options.HttpAuth.isSet()
+	}
+	return false
+}
+
+// openHttpDownload is a full download: the whole object is copied to a temporary file with
+// ranged GETs and the local copy is opened, so the file must fit on local disk.  Lazy reads
+// of http(s) urls without authentication are served by ROS3.  The temporary file is unlinked
+// once open and its space is freed when the file is closed.
+func openHttpDownload(url string, options HdfFileOptions) (*hdf5.File, error) {
+	r, err := NewHttpReaderAt(url, options.HttpAuth, options.HttpClient)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp("", "hdf5utils-*.h5")
+	if err != nil {
+		return nil, newHdfError("open file", url, nil, err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = r.WriteTo(tmp)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return openLocalFile(tmp.Name(), hdf5.F_ACC_RDONLY)
+}
+
+// WriteTo copies the remote object to w using ranged GETs of httpBlockSize bytes
+func (r *HttpReaderAt) WriteTo(w io.Writer) (int64, error) {
+	block := make([]byte, httpBlockSize)
+	var written int64
+	for off := int64(0); off < r.size; off += httpBlockSize {
+		n, err := r.ReadAt(block, off)
+		if err != nil && err != io.EOF {
+			return written, err
+		}
+		n, err = w.Write(block[:n])
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
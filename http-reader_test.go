@@ -0,0 +1,190 @@
+package hdf5utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// rangeServer serves content with range request support, recording the Authorization header of each request
+func rangeServer(t *testing.T, content []byte, auth *[]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth != nil {
+			*auth = append(*auth, r.Header.Get("Authorization"))
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.h5", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHttpReaderAtRanges(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	srv := rangeServer(t, content, nil)
+
+	r, err := NewHttpReaderAt(srv.URL+"/file.h5", HttpAuth{}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Size() != int64(len(content)) {
+		t.Errorf("got size %d, want %d", r.Size(), len(content))
+	}
+	if r.ETag() != `"v1"` {
+		t.Errorf("got etag %s", r.ETag())
+	}
+
+	p := make([]byte, 5)
+	n, err := r.ReadAt(p, 10)
+	if err != nil || n != 5 || string(p) != "abcde" {
+		t.Errorf("got %d bytes '%s', %v", n, p[:n], err)
+	}
+	n, err = r.ReadAt(p, 17)
+	if err != io.EOF || n != 3 || string(p[:n]) != "hij" {
+		t.Errorf("short read at the end: got %d bytes '%s', %v", n, p[:n], err)
+	}
+	if _, err = r.ReadAt(p, 20); err != io.EOF {
+		t.Errorf("read past the end: got %v, want io.EOF", err)
+	}
+
+	var buf bytes.Buffer
+	written, err := r.WriteTo(&buf)
+	if err != nil || written != int64(len(content)) || !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("WriteTo copied %d bytes, %v", written, err)
+	}
+}
+
+func TestHttpReaderAtWithoutRangeSupport(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content) //ignores the Range header and always returns 200
+	}))
+	defer srv.Close()
+
+	r, err := NewHttpReaderAt(srv.URL, HttpAuth{}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 5)
+	n, err := r.ReadAt(p, 0)
+	if err != nil || string(p[:n]) != "01234" {
+		t.Errorf("a full response is usable for a read at offset 0, got '%s', %v", p[:n], err)
+	}
+	_, err = r.ReadAt(p, 10)
+	if !errors.Is(err, ErrRemoteAccess) {
+		t.Errorf("got %v, want ErrRemoteAccess", err)
+	}
+}
+
+func TestHttpReaderAtAuth(t *testing.T) {
+	var auth []string
+	srv := rangeServer(t, []byte("content"), &auth)
+
+	_, err := NewHttpReaderAt(srv.URL, HttpAuth{BearerToken: "token"}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(auth) == 0 || auth[0] != "Bearer token" {
+		t.Errorf("got Authorization %v, want Bearer token", auth)
+	}
+
+	auth = nil
+	_, err = NewHttpReaderAt(srv.URL, HttpAuth{Username: "user", Password: "pass"}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.SetBasicAuth("user", "pass")
+	if len(auth) == 0 || auth[0] != req.Header.Get("Authorization") {
+		t.Errorf("got Authorization %v, want basic auth", auth)
+	}
+
+	var header string
+	hsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Api-Key")
+		w.Write([]byte("content"))
+	}))
+	defer hsrv.Close()
+	_, err = NewHttpReaderAt(hsrv.URL, HttpAuth{Headers: map[string]string{"X-Api-Key": "key"}}, hsrv.Client())
+	if err != nil || header != "key" {
+		t.Errorf("got header '%s', %v", header, err)
+	}
+}
+
+func TestHttpReaderAtStatusErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrFileNotFound},
+		{http.StatusUnauthorized, ErrAccessDenied},
+		{http.StatusForbidden, ErrAccessDenied},
+		{http.StatusServiceUnavailable, ErrRemoteAccess},
+	}
+	for _, tc := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+		}))
+		_, err := NewHttpReaderAt(srv.URL, HttpAuth{}, srv.Client())
+		srv.Close()
+		if !errors.Is(err, tc.want) {
+			t.Errorf("status %d: got %v, want %v", tc.status, err, tc.want)
+		}
+	}
+}
+
+func TestOpenHttpFile(t *testing.T) {
+	values := []float64{1.5, 2.5, 3.5}
+	var auth []string
+	srv := rangeServer(t, fixtureBytes(t, "/values", &values, []uint{3}), &auth)
+
+	f, err := OpenFileWithOptions(srv.URL+"/file.h5", HdfFileOptions{
+		Backend:    BackendHttpDownload,
+		HttpAuth:   HttpAuth{BearerToken: "token"},
+		HttpClient: srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data := readFixture(t, f, "/values", reflect.Float64)
+	if got := *data.Buffer.(*[]float64); !reflect.DeepEqual(got, values) {
+		t.Errorf("got %v, want %v", got, values)
+	}
+	for _, a := range auth {
+		if a != "Bearer token" {
+			t.Errorf("request sent without the bearer token: '%s'", a)
+		}
+	}
+}
+
+func TestUseHttpBackend(t *testing.T) {
+	tests := []struct {
+		url     string
+		options HdfFileOptions
+		want    bool
+	}{
+		{"s3://bucket/key.h5", HdfFileOptions{}, false},
+		{"https://bucket.s3.us-west-2.amazonaws.com/key.h5", HdfFileOptions{}, false},
+		{"https://data.example.com/key.h5", HdfFileOptions{}, false},
+		{"http://localhost:9000/bucket/key.h5", HdfFileOptions{S3Endpoint: "http://localhost:9000"}, false},
+		{"https://data.example.com/key.h5", HdfFileOptions{HttpAuth: HttpAuth{BearerToken: "token"}}, true},
+		{"https://data.example.com/key.h5", HdfFileOptions{HttpAuth: HttpAuth{Headers: map[string]string{"X-Api-Key": "key"}}}, true},
+		{"s3://bucket/key.h5", HdfFileOptions{HttpAuth: HttpAuth{BearerToken: "token"}}, false},
+		{"https://data.example.com/key.h5", HdfFileOptions{Backend: BackendRos3}, false},
+		{"https://data.example.com/key.h5", HdfFileOptions{Backend: BackendRos3, HttpAuth: HttpAuth{BearerToken: "token"}}, false},
+		{"https://data.example.com/key.h5", HdfFileOptions{Backend: BackendHttpDownload}, true},
+		{"http://data.example.com/key.h5", HdfFileOptions{Backend: BackendHttpDownload}, true},
+		{"s3://bucket/key.h5", HdfFileOptions{Backend: BackendHttpDownload}, false},
+	}
+	for i, tc := range tests {
+		if got := useHttpBackend(tc.url, tc.options); got != tc.want {
+			t.Errorf("case %d (%s): got %t, want %t", i, tc.url, got, tc.want)
+		}
+	}
+}
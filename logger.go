@@ -0,0 +1,99 @@
+package hdf5utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Logger receives the package diagnostics.  Arguments after the message are alternating
+// key/value pairs such as "file", path.  A *slog.Logger satisfies this interface.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+type loggerHolder struct {
+	Logger
+}
+
+var currentLogger atomic.Value
+
+func init() {
+	currentLogger.Store(loggerHolder{nopLogger{}})
+}
+
+// SetLogger routes the package diagnostics to l.  Logging is silent by default
+// and a nil Logger silences it again.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	currentLogger.Store(loggerHolder{l})
+}
+
+func logger() Logger {
+	return currentLogger.Load().(loggerHolder).Logger
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...interface{}) {}
+func (nopLogger) Info(msg string, args ...interface{})  {}
+func (nopLogger) Warn(msg string, args ...interface{})  {}
+func (nopLogger) Error(msg string, args ...interface{}) {}
+
+// StdLogger adapts a standard library logger, writing messages at or above Level
+// as "LEVEL msg key=value ..."
+type StdLogger struct {
+	Logger *log.Logger //defaults to a logger writing to stderr
+	Level  LogLevel
+}
+
+var stderrLogger = log.New(os.Stderr, "", log.LstdFlags)
+
+func NewStdLogger(l *log.Logger, level LogLevel) *StdLogger {
+	return &StdLogger{Logger: l, Level: level}
+}
+
+func (s *StdLogger) Debug(msg string, args ...interface{}) { s.log(LevelDebug, msg, args) }
+func (s *StdLogger) Info(msg string, args ...interface{})  { s.log(LevelInfo, msg, args) }
+func (s *StdLogger) Warn(msg string, args ...interface{})  { s.log(LevelWarn, msg, args) }
+func (s *StdLogger) Error(msg string, args ...interface{}) { s.log(LevelError, msg, args) }
+
+func (s *StdLogger) log(level LogLevel, msg string, args []interface{}) {
+	if level < s.Level {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(levelNames[level])
+	sb.WriteString(" ")
+	sb.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&sb, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&sb, " %v", args[i])
+		}
+	}
+	l := s.Logger
+	if l == nil {
+		l = stderrLogger
+	}
+	l.Output(3, sb.String())
+}
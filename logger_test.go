@@ -0,0 +1,28 @@
+package hdf5utils
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(log.New(&buf, "", 0), LevelInfo)
+	l.Debug("reading subset", "rows", []int{0, 1})
+	l.Info("opened dataset", "file", "model.h5", "dims", []uint{2, 3})
+	l.Warn("odd arguments", "file")
+	want := "INFO opened dataset file=model.h5 dims=[2 3]\nWARN odd arguments file\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	SetLogger(l)
+	logger().Error("read failed")
+	SetLogger(nil)
+	logger().Error("not logged")
+	if got := buf.String(); got != "ERROR read failed\n" {
+		t.Errorf("package logger: got %q", got)
+	}
+}
@@ -0,0 +1,47 @@
+package hdf5utils
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// OpenBytes opens an HDF5 file image held in memory using the core driver.
+// The image is copied by HDF5 so buf can be reused once OpenBytes returns.
+// The returned file is read-only and can be passed to HdfReadOptions.File.
+func OpenBytes(buf []byte) (*hdf5.File, error) {
+	fapl, err := hdf5.NewPropList(hdf5.P_FILE_ACCESS)
+	if err != nil {
+		return nil, err
+	}
+	defer fapl.Close()
+
+	err = setFaplCoreImage(fapl.ID(), buf)
+	if err != nil {
+		return nil, newHdfError("open file", "memory", nil, err)
+	}
+
+	//the core driver still requires a unique name for the file
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("memory-%s.h5", id.String())
+	f, err := hdf5.OpenFileWithProp(name, hdf5.F_ACC_RDONLY, fapl)
+	if err != nil {
+		return nil, newHdfError("open file", "memory", nil, err)
+	}
+	return f, nil
+}
+
+// OpenReaderAt reads size bytes from r and opens them as an in-memory HDF5 file
+func OpenReaderAt(r io.ReaderAt, size int64) (*hdf5.File, error) {
+	buf := make([]byte, size)
+	n, err := r.ReadAt(buf, 0)
+	if err != nil && !(err == io.EOF && int64(n) == size) {
+		return nil, newHdfError("open file", "reader", nil, err)
+	}
+	return OpenBytes(buf)
+}
@@ -0,0 +1,72 @@
+package hdf5utils
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// fixtureBytes returns the file image of fixtureFile
+func fixtureBytes(t *testing.T, datapath string, src interface{}, dims []uint) []byte {
+	t.Helper()
+	buf, err := os.ReadFile(fixtureFile(t, datapath, src, dims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+// readFixture reads a dataset of a file opened by a test as the given kind
+func readFixture(t *testing.T, f *hdf5.File, datapath string, dtype reflect.Kind) *HdfData {
+	t.Helper()
+	dset, err := NewHdfDataset(datapath, HdfReadOptions{Dtype: dtype, File: f, ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	return dset.Data
+}
+
+func TestOpenBytes(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6}
+	buf := fixtureBytes(t, "/group/values", &values, []uint{2, 3})
+
+	f, err := OpenBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for i := range buf {
+		buf[i] = 0 //the image is copied so the buffer can be reused
+	}
+
+	data := readFixture(t, f, "/group/values", reflect.Float64)
+	if !reflect.DeepEqual(data.Dims, []uint{2, 3}) {
+		t.Errorf("got dims %v, want [2 3]", data.Dims)
+	}
+	if got := *data.Buffer.(*[]float64); !reflect.DeepEqual(got, values) {
+		t.Errorf("got %v, want %v", got, values)
+	}
+}
+
+func TestOpenReaderAt(t *testing.T) {
+	values := []int32{7, 8, 9}
+	buf := fixtureBytes(t, "/values", &values, []uint{3})
+
+	f, err := OpenReaderAt(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data := readFixture(t, f, "/values", reflect.Int32)
+	if got := *data.Buffer.(*[]int32); !reflect.DeepEqual(got, values) {
+		t.Errorf("got %v, want %v", got, values)
+	}
+
+	if _, err := OpenBytes([]byte("not an hdf5 file")); err == nil {
+		t.Error("expected an error opening an invalid image")
+	}
+}
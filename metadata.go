@@ -1,6 +1,7 @@
 package hdf5utils
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 
@@ -18,6 +19,22 @@ const (
 type GoHdfAttr struct {
 	AttrType reflect.Type
 	AttrSize uint
+	Enum     *HdfEnum //name/value mapping of enum types, nil for other types
+}
+
+func newGoHdfAttr(dtype *hdf5.Datatype) (*GoHdfAttr, error) {
+	attr := GoHdfAttr{
+		AttrType: dtype.GoType(),
+		AttrSize: dtype.Size(),
+	}
+	if dtype.Class() == hdf5.T_ENUM {
+		enum, err := readEnum(dtype)
+		if err != nil {
+			return nil, err
+		}
+		attr.Enum = enum
+	}
+	return &attr, nil
 }
 
 func GetAttrMetadata(f *hdf5.File, metaType Hdf5MetadataType, metaPath string, metaField string) (*GoHdfAttr, error) {
@@ -25,7 +42,7 @@ func GetAttrMetadata(f *hdf5.File, metaType Hdf5MetadataType, metaPath string, m
 	switch metaType {
 
 	case DatasetMetadata:
-		dset, err := f.OpenDataset(metaPath)
+		dset, err := openDataset(f, metaPath)
 		if err != nil {
 			return nil, err
 		}
@@ -37,18 +54,21 @@ func GetAttrMetadata(f *hdf5.File, metaType Hdf5MetadataType, metaPath string, m
 		}
 		defer dtype.Close()
 
-		return &GoHdfAttr{
-			AttrType: dtype.GoType(),
-			AttrSize: dtype.Size(),
-		}, nil
+		return newGoHdfAttr(dtype)
 
 	case GroupMetadata:
+		if metaPath != "/" && !f.LinkExists(metaPath) {
+			return nil, newHdfError("open group", metaPath, ErrDatasetNotFound, errors.New("group does not exist"))
+		}
 		grp, err := f.OpenGroup(metaPath)
 		if err != nil {
 			return nil, err
 		}
 		defer grp.Close()
 
+		if !grp.AttributeExists(metaField) {
+			return nil, newHdfError("open attribute", metaPath+"/"+metaField, ErrDatasetNotFound, errors.New("attribute does not exist"))
+		}
 		attr, err := grp.OpenAttribute(metaField)
 		if err != nil {
 			return nil, err
@@ -60,13 +80,10 @@ func GetAttrMetadata(f *hdf5.File, metaType Hdf5MetadataType, metaPath string, m
 		dt := hdf5.NewDatatype(attrType.HID())
 		defer dt.Close()
 
-		return &GoHdfAttr{
-			AttrType: dt.GoType(),
-			AttrSize: dt.Size(),
-		}, nil
+		return newGoHdfAttr(dt)
 
 	case CompoundMetadata:
-		dset, err := f.OpenDataset(metaPath)
+		dset, err := openDataset(f, metaPath)
 		if err != nil {
 			return nil, err
 		}
@@ -92,12 +109,10 @@ func GetAttrMetadata(f *hdf5.File, metaType Hdf5MetadataType, metaPath string, m
 				}
 				defer mftype.Close()
 
-				return &GoHdfAttr{
-					AttrType: mftype.GoType(),
-					AttrSize: mftype.Size(),
-				}, nil
+				return newGoHdfAttr(mftype)
 			}
 		}
+		return nil, newHdfError("read compound member", metaPath+"/"+metaField, ErrDatasetNotFound, errors.New("compound member does not exist"))
 	}
 
 	return nil, fmt.Errorf("invalid meta type: %s", metaType)
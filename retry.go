@@ -0,0 +1,127 @@
+package hdf5utils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+)
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultRetryMaxBackoff = 10 * time.Second
+	defaultRetryMultiplier = 2.0
+)
+
+// RetryPolicy retries transient failures opening and reading remote files using
+// exponential backoff with jitter.  Zero valued fields use the package defaults.
+// Local files are never retried.
+type RetryPolicy struct {
+	MaxAttempts int              //total attempts including the first, defaults to 3
+	Backoff     time.Duration    //delay before the first retry, defaults to 200ms
+	MaxBackoff  time.Duration    //upper bound on the delay between attempts, defaults to 10s
+	Multiplier  float64          //growth factor of the delay, defaults to 2
+	Jitter      float64          //fraction 0-1 of each delay that is randomized
+	Retryable   func(error) bool //error classifier, defaults to IsRetryable
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{Jitter: 0.5}
+}
+
+// IsRetryable reports whether err is likely a transient remote failure.
+// Missing files or datasets, denied access, invalid selections, unsupported or
+// mismatched types, unsupported operations and cancelled contexts are permanent.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, permanent := range []error{
+		context.Canceled, context.DeadlineExceeded,
+		ErrFileNotFound, ErrDatasetNotFound, ErrAccessDenied,
+		ErrOutOfRange, ErrUnsupportedDatatype, ErrRemoteWrite,
+		ErrDatatypeMismatch, ErrLossyConversion, ErrUnsupportedOperation,
+	} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	if errors.Is(err, ErrRemoteAccess) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// do calls fn until it succeeds, returns a permanent error or the attempts run out.
+// retry is called before each retry, for example to reopen a file handle; a failing
+// retry hook counts as a failed attempt.  A nil policy makes a single attempt.  The
+// wait between attempts ends early with ctx.Err() when ctx is done.
+func (p *RetryPolicy) do(ctx context.Context, fn func() error, retry func() error) error {
+	err := fn()
+	if p == nil {
+		return err
+	}
+	for attempt := 1; attempt < p.maxAttempts() && err != nil && p.retryable(err); attempt++ {
+		if cerr := p.wait(ctx, attempt); cerr != nil {
+			return cerr
+		}
+		if retry != nil {
+			if rerr := retry(); rerr != nil {
+				err = rerr
+				continue
+			}
+		}
+		err = fn()
+	}
+	return err
+}
+
+// wait sleeps for the backoff before retry number attempt or until ctx is done
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.delay(attempt))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts > 0 {
+		return p.MaxAttempts
+	}
+	return defaultRetryAttempts
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// delay returns the backoff before retry number attempt (starting at 1)
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	backoff, maxBackoff, multiplier := p.Backoff, p.MaxBackoff, p.Multiplier
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+	d := math.Min(float64(backoff)*math.Pow(multiplier, float64(attempt-1)), float64(maxBackoff))
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
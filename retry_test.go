@@ -0,0 +1,171 @@
+package hdf5utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	p := &RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	want := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if d := p.delay(i + 1); d != w {
+			t.Errorf("attempt %d: got %s, want %s", i+1, d, w)
+		}
+	}
+
+	defaults := &RetryPolicy{}
+	if d := defaults.delay(1); d != defaultRetryBackoff {
+		t.Errorf("default backoff: got %s, want %s", d, defaultRetryBackoff)
+	}
+	if d := defaults.delay(100); d != defaultRetryMaxBackoff {
+		t.Errorf("default max backoff: got %s, want %s", d, defaultRetryMaxBackoff)
+	}
+
+	jittered := &RetryPolicy{Backoff: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if d := jittered.delay(1); d < 50*time.Millisecond || d > 100*time.Millisecond {
+			t.Fatalf("jittered delay %s outside [50ms, 100ms]", d)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("unclassified"), false},
+		{newHdfError("open file", "s3://bucket/key", ErrRemoteAccess, errors.New("timeout")), true},
+		{fmt.Errorf("read: %w", &net.OpError{Op: "read", Err: errors.New("connection reset")}), true},
+		{newHdfError("open file", "file.h5", ErrFileNotFound, nil), false},
+		{newHdfError("open dataset", "/a", ErrDatasetNotFound, nil), false},
+		{newHdfError("open file", "s3://bucket/key", ErrAccessDenied, nil), false},
+		{newHdfError("read subset", "/a", ErrOutOfRange, nil), false},
+		{newHdfError("open dataset", "/a", ErrDatatypeMismatch, nil), false},
+		{fmt.Errorf("read: %w", ErrLossyConversion), false},
+		{fmt.Errorf("refresh: %w", ErrUnsupportedOperation), false},
+		{context.Canceled, false},
+		{fmt.Errorf("read: %w", context.DeadlineExceeded), false},
+	}
+	for i, tc := range tests {
+		if got := IsRetryable(tc.err); got != tc.want {
+			t.Errorf("case %d (%v): got %t, want %t", i, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := newHdfError("read", "/a", ErrRemoteAccess, errors.New("timeout"))
+	p := &RetryPolicy{MaxAttempts: 4, Backoff: time.Millisecond}
+
+	calls, reopens := 0, 0
+	err := p.do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return transient
+		}
+		return nil
+	}, func() error {
+		reopens++
+		return nil
+	})
+	if err != nil || calls != 3 || reopens != 2 {
+		t.Errorf("got err %v after %d calls and %d reopens, want success after 3 calls and 2 reopens", err, calls, reopens)
+	}
+
+	calls = 0
+	err = p.do(context.Background(), func() error {
+		calls++
+		return transient
+	}, nil)
+	if !errors.Is(err, ErrRemoteAccess) || calls != 4 {
+		t.Errorf("got err %v after %d calls, want ErrRemoteAccess after 4 calls", err, calls)
+	}
+
+	calls = 0
+	err = p.do(context.Background(), func() error {
+		calls++
+		return newHdfError("read subset", "/a", ErrOutOfRange, nil)
+	}, nil)
+	if !errors.Is(err, ErrOutOfRange) || calls != 1 {
+		t.Errorf("permanent errors must not be retried, got %d calls", calls)
+	}
+
+	var nilPolicy *RetryPolicy
+	calls = 0
+	nilPolicy.do(context.Background(), func() error {
+		calls++
+		return transient
+	}, nil)
+	if calls != 1 {
+		t.Errorf("a nil policy must make a single attempt, got %d calls", calls)
+	}
+
+	slow := &RetryPolicy{MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	calls = 0
+	start := time.Now()
+	err = slow.do(ctx, func() error {
+		calls++
+		return transient
+	}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || calls != 1 {
+		t.Errorf("got err %v after %d calls, want context.DeadlineExceeded after 1 call", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("a done context must end the backoff, waited %s", elapsed)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{errors.New("H5Dread failed"), nil},
+		{newHdfError("read dimensions", "/a", nil, errors.New("H5Sget_simple_extent_dims failed")), nil},
+		{newHdfError("read subset", "/a", ErrOutOfRange, nil), ErrOutOfRange},
+		{fmt.Errorf("field x: %w", ErrDatatypeMismatch), ErrDatatypeMismatch},
+		{fmt.Errorf("reading float64 as float32: %w", ErrLossyConversion), ErrLossyConversion},
+		{newHdfError("open dataset", "/a", ErrDatasetNotFound, nil), ErrDatasetNotFound},
+		{fmt.Errorf("the dataset reader does not support refresh: %w", ErrUnsupportedOperation), ErrUnsupportedOperation},
+	}
+	for i, tc := range tests {
+		if got := errorKind(tc.err); got != tc.want {
+			t.Errorf("case %d (%v): got %v, want %v", i, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestReaderRetryClassification(t *testing.T) {
+	h := &HdfReaderSync{
+		filepath:    "s3://bucket/key.h5",
+		datapath:    "/a",
+		fileOptions: HdfFileOptions{Retry: &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}},
+	}
+
+	calls := 0
+	err := h.retry(context.Background(), "read", func() error {
+		calls++
+		return fmt.Errorf("unable to read dataset: %w", errIOFailure)
+	})
+	if !errors.Is(err, ErrRemoteAccess) || calls != 3 {
+		t.Errorf("I/O failures: got err %v after %d calls, want ErrRemoteAccess after 3 calls", err, calls)
+	}
+
+	calls = 0
+	err = h.retry(context.Background(), "read", func() error {
+		calls++
+		return errors.New("unable to read dataset")
+	})
+	if errors.Is(err, ErrRemoteAccess) || calls != 1 {
+		t.Errorf("other HDF5 failures: got err %v after %d calls, want the error unchanged after 1 call", err, calls)
+	}
+}
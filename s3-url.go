@@ -0,0 +1,98 @@
+package hdf5utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	DEFAULT_S3_REGION = "us-east-1"
+	S3_SCHEME         = "s3://"
+)
+
+// S3Location describes an object addressed with an s3://bucket/key url
+type S3Location struct {
+	Bucket    string
+	Key       string
+	Region    string
+	Endpoint  string //optional custom endpoint (e.g. http://localhost:9000 for minio)
+	PathStyle bool   //use https://endpoint/bucket/key rather than https://bucket.endpoint/key
+}
+
+func isS3Url(url string) bool {
+	return strings.HasPrefix(url, S3_SCHEME)
+}
+
+func isHttpUrl(url string) bool {
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")
+}
+
+// ParseS3Url splits an s3://bucket/key url into its bucket and key
+func ParseS3Url(s3url string) (S3Location, error) {
+	if !isS3Url(s3url) {
+		return S3Location{}, fmt.Errorf("invalid s3 url '%s': missing %s prefix", s3url, S3_SCHEME)
+	}
+	path := strings.TrimPrefix(s3url, S3_SCHEME)
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return S3Location{}, fmt.Errorf("invalid s3 url '%s': expected s3://bucket/key", s3url)
+	}
+	return S3Location{
+		Bucket: parts[0],
+		Key:    parts[1],
+	}, nil
+}
+
+// HttpUrl returns the http(s) url the ROS3 driver should use for the object.
+// Buckets containing dots are always addressed path-style since virtual-host
+// addressing breaks TLS validation for them.
+func (s S3Location) HttpUrl() (string, error) {
+	if s.Bucket == "" || s.Key == "" {
+		return "", errors.New("s3 location requires a bucket and key")
+	}
+	key := escapeS3Key(s.Key)
+	if s.Endpoint != "" {
+		endpoint, err := url.Parse(s.Endpoint)
+		if err != nil {
+			return "", fmt.Errorf("invalid s3 endpoint '%s': %s", s.Endpoint, err)
+		}
+		if endpoint.Scheme == "" || endpoint.Host == "" {
+			return "", fmt.Errorf("invalid s3 endpoint '%s': expected scheme://host[:port]", s.Endpoint)
+		}
+		base := strings.TrimSuffix(endpoint.Path, "/")
+		if s.PathStyle || strings.Contains(s.Bucket, ".") {
+			return fmt.Sprintf("%s://%s%s/%s/%s", endpoint.Scheme, endpoint.Host, base, s.Bucket, key), nil
+		}
+		return fmt.Sprintf("%s://%s.%s%s/%s", endpoint.Scheme, s.Bucket, endpoint.Host, base, key), nil
+	}
+
+	region := s.Region
+	if region == "" {
+		region = DEFAULT_S3_REGION
+	}
+	if s.PathStyle || strings.Contains(s.Bucket, ".") {
+		return fmt.Sprintf("https://s3.%s.amazonaws.com/%s/%s", region, s.Bucket, key), nil
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, region, key), nil
+}
+
+// escapeS3Key percent encodes every byte of the key other than unreserved characters and
+// the path separators.  Sub-delimiters such as '+' are encoded because some S3 endpoints
+// read a literal '+' in the path as a space.
+func escapeS3Key(key string) string {
+	return sigv4Escape(key, false)
+}
+
+// ResolveS3Url converts an s3:// url into the regional http(s) url used by the ROS3 driver
+func ResolveS3Url(s3url string, region string, endpoint string, pathStyle bool) (string, error) {
+	loc, err := ParseS3Url(s3url)
+	if err != nil {
+		return "", err
+	}
+	loc.Region = region
+	loc.Endpoint = endpoint
+	loc.PathStyle = pathStyle
+	return loc.HttpUrl()
+}
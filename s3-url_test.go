@@ -0,0 +1,57 @@
+package hdf5utils
+
+import "testing"
+
+func TestParseS3Url(t *testing.T) {
+	loc, err := ParseS3Url("s3://my-bucket/path/to/file.h5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Bucket != "my-bucket" || loc.Key != "path/to/file.h5" {
+		t.Errorf("got bucket '%s' key '%s'", loc.Bucket, loc.Key)
+	}
+
+	for _, bad := range []string{"https://bucket/key", "s3://bucket", "s3://bucket/", "s3:///key"} {
+		if _, err := ParseS3Url(bad); err == nil {
+			t.Errorf("expected an error parsing '%s'", bad)
+		}
+	}
+}
+
+func TestResolveS3Url(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		region    string
+		endpoint  string
+		pathStyle bool
+		want      string
+	}{
+		{"default region", "s3://bucket/a/b.h5", "", "", false, "https://bucket.s3.us-east-1.amazonaws.com/a/b.h5"},
+		{"regional", "s3://bucket/b.h5", "us-west-2", "", false, "https://bucket.s3.us-west-2.amazonaws.com/b.h5"},
+		{"path style", "s3://bucket/b.h5", "us-west-2", "", true, "https://s3.us-west-2.amazonaws.com/bucket/b.h5"},
+		{"dotted bucket", "s3://my.bucket/b.h5", "us-west-2", "", false, "https://s3.us-west-2.amazonaws.com/my.bucket/b.h5"},
+		{"escaped key", "s3://bucket/dir name/a+b.h5", "us-east-1", "", false, "https://bucket.s3.us-east-1.amazonaws.com/dir%20name/a%2Bb.h5"},
+		{"sub-delimiters in key", "s3://bucket/runs/a=1&b,c(2).h5", "us-east-1", "", false, "https://bucket.s3.us-east-1.amazonaws.com/runs/a%3D1%26b%2Cc%282%29.h5"},
+		{"custom endpoint", "s3://bucket/b.h5", "", "http://localhost:9000", true, "http://localhost:9000/bucket/b.h5"},
+		{"virtual host endpoint", "s3://bucket/b.h5", "", "https://storage.example.com", false, "https://bucket.storage.example.com/b.h5"},
+		{"endpoint with path", "s3://bucket/b.h5", "", "https://example.com/s3/", true, "https://example.com/s3/bucket/b.h5"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResolveS3Url(tc.url, tc.region, tc.endpoint, tc.pathStyle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveS3UrlInvalidEndpoint(t *testing.T) {
+	if _, err := ResolveS3Url("s3://bucket/b.h5", "", "localhost:9000", true); err == nil {
+		t.Error("expected an error for an endpoint without a scheme")
+	}
+}
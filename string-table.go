@@ -0,0 +1,190 @@
+package hdf5utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// StringTableOptions names the columns of a string table.  Column names are taken from
+// Headers, then from the HeaderAttribute of the dataset, then from the member names of
+// compound string datasets.
+type StringTableOptions struct {
+	Headers         []string //column names supplied by the caller
+	HeaderAttribute string   //string array attribute of the dataset holding the column names
+}
+
+// ReadStringTable reads a fixed or variable length string table into one map per row
+// keyed by column name
+func ReadStringTable(f *hdf5.File, datapath string, options StringTableOptions) ([]map[string]string, error) {
+	headers, rows, _, err := readStringTable(f, datapath, options)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		record := make(map[string]string, len(headers))
+		for j, h := range headers {
+			record[h] = row[j]
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// ReadStringTableInto reads a string table into a pointer to a slice of structs.  String
+// fields are bound to columns by their hdf tag, or their field name when untagged.  A strlen
+// tag must match the column width in the file.  Columns without a field are skipped.
+func ReadStringTableInto(f *hdf5.File, datapath string, options StringTableOptions, dest interface{}) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Slice || dv.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("string tables must be read into a pointer to a slice of structs, not %T", dest)
+	}
+	typ := dv.Elem().Type().Elem()
+
+	headers, rows, sizes, err := readStringTable(f, datapath, options)
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(headers))
+	for j, h := range headers {
+		columns[h] = j
+	}
+
+	type binding struct {
+		field  int
+		column int
+	}
+	bindings := []binding{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue //unexported
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("hdf"); ok {
+			name, _ = parseHdfTag(tag)
+		}
+		if name == "-" {
+			continue
+		}
+		col, ok := columns[name]
+		if !ok {
+			return newHdfError("read string table", datapath, ErrDatatypeMismatch, fmt.Errorf("field %s is bound to column '%s' which is not in the table", field.Name, name))
+		}
+		if field.Type.Kind() != reflect.String {
+			return fmt.Errorf("field %s of kind %s: %w", field.Name, field.Type.Kind(), ErrUnsupportedDatatype)
+		}
+		if strlen, ok := field.Tag.Lookup(stringLengthTag); ok {
+			err := checkColumnWidth(sizes, datapath, col, strlen)
+			if err != nil {
+				return err
+			}
+		}
+		bindings = append(bindings, binding{i, col})
+	}
+
+	out := reflect.MakeSlice(dv.Elem().Type(), len(rows), len(rows))
+	for i, row := range rows {
+		s := out.Index(i)
+		for _, b := range bindings {
+			s.Field(b.field).SetString(row[b.column])
+		}
+	}
+	dv.Elem().Set(out)
+	return nil
+}
+
+// readStringTable returns the column names, the rows and the column widths of a string table.
+// The widths are empty for variable length tables.
+func readStringTable(f *hdf5.File, datapath string, options StringTableOptions) ([]string, [][]string, HdfStrSet, error) {
+	dset, err := NewHdfDataset(datapath, HdfReadOptions{
+		Dtype:        reflect.String,
+		File:         f,
+		ReadOnCreate: true,
+	})
+	if err != nil {
+		return nil, nil, HdfStrSet{}, err
+	}
+	defer dset.Close()
+
+	rows := make([][]string, dset.Rows())
+	for i := range rows {
+		row := []string{}
+		err = dset.ReadRow(i, &row)
+		if err != nil {
+			return nil, nil, HdfStrSet{}, err
+		}
+		rows[i] = row
+	}
+
+	headers, err := tableHeaders(f, datapath, options)
+	if err != nil {
+		return nil, nil, HdfStrSet{}, err
+	}
+	cols := dset.Cols()
+	if n := len(dset.options.Strsizes.Cols()); n > 0 {
+		cols = n //fixed length tables, including compound tables stored as 1-D datasets
+	}
+	if len(headers) != cols {
+		return nil, nil, HdfStrSet{}, newHdfError("read string table", datapath, ErrDatatypeMismatch, fmt.Errorf("%d column names for a table with %d columns", len(headers), cols))
+	}
+	return headers, rows, dset.options.Strsizes, nil
+}
+
+func tableHeaders(f *hdf5.File, datapath string, options StringTableOptions) ([]string, error) {
+	if len(options.Headers) > 0 {
+		return options.Headers, nil
+	}
+	dset, err := openDataset(f, datapath)
+	if err != nil {
+		return nil, err
+	}
+	defer dset.Close()
+
+	if options.HeaderAttribute != "" {
+		if !attributeExists(dset.ID(), options.HeaderAttribute) {
+			return nil, newHdfError("read column names", datapath+"/"+options.HeaderAttribute, ErrDatasetNotFound, errors.New("attribute does not exist"))
+		}
+		attr, err := dset.OpenAttribute(options.HeaderAttribute)
+		if err != nil {
+			return nil, newHdfError("read column names", datapath+"/"+options.HeaderAttribute, nil, err)
+		}
+		defer attr.Close()
+		headers, err := readAttrStrings(attr.ID())
+		if err != nil {
+			return nil, newHdfError("read column names", datapath+"/"+options.HeaderAttribute, nil, err)
+		}
+		return headers, nil
+	}
+
+	dtype, err := dset.Datatype()
+	if err != nil {
+		return nil, err
+	}
+	defer dtype.Close()
+	if dtype.Class() == hdf5.T_COMPOUND {
+		ctype := hdf5.CompoundType{*dtype}
+		headers := make([]string, ctype.NMembers())
+		for i := range headers {
+			headers[i] = ctype.MemberName(i)
+		}
+		return headers, nil
+	}
+	return nil, newHdfError("read column names", datapath, nil, fmt.Errorf("the table has no member names, supply Headers or a HeaderAttribute"))
+}
+
+// checkColumnWidth compares the width of a column with the strlen tag of the field bound to it
+func checkColumnWidth(sizes HdfStrSet, datapath string, col int, strlen string) error {
+	width, err := strconv.Atoi(strlen)
+	if err != nil {
+		return fmt.Errorf("invalid string length '%s'", strlen)
+	}
+	if sz, _ := sizes.Size(col); sz != width {
+		return newHdfError("read string table", datapath, ErrDatatypeMismatch, fmt.Errorf("column %d is %d bytes wide, the field expects %d", col, sz, width))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package hdf5utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// stringTableFixture writes a fixed length string table with columns of 4, 9 and 4 bytes,
+// stored as a compound dataset, and a "columns" attribute naming the columns
+func stringTableFixture(t *testing.T) *hdf5.File {
+	t.Helper()
+	f := newFixture(t, "table.h5")
+
+	w, err := NewHdfWriterSync("/table", HdfWriteOptions{Dtype: reflect.String, File: f})
+	must(t, err)
+	err = w.WriteFrom([][]string{
+		{"AB1", "gauge one", "ok"},
+		{"CD22", "gauge two", "down"},
+	})
+	w.Close()
+	must(t, err)
+
+	dset, err := openDataset(f, "/table")
+	must(t, err)
+	defer dset.Close()
+	dtype, err := stringDatatype(8)
+	must(t, err)
+	defer dtype.Close()
+	space, err := hdf5.CreateSimpleDataspace([]uint{3}, nil)
+	must(t, err)
+	defer space.Close()
+	attr, err := dset.CreateAttribute("columns", dtype, space)
+	must(t, err)
+	defer attr.Close()
+	var names [24]byte
+	copy(names[0:], "id")
+	copy(names[8:], "name")
+	copy(names[16:], "status")
+	must(t, attr.Write(&names, dtype))
+	return f
+}
+
+func TestReadStringTable(t *testing.T) {
+	f := stringTableFixture(t)
+
+	records, err := ReadStringTable(f, "/table", StringTableOptions{HeaderAttribute: "columns"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		{"id": "AB1", "name": "gauge one", "status": "ok"},
+		{"id": "CD22", "name": "gauge two", "status": "down"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %v, want %v", records, want)
+	}
+
+	//compound member names are used without headers
+	records, err = ReadStringTable(f, "/table", StringTableOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[1]["1"] != "gauge two" {
+		t.Errorf("got %v, want member 1 of row 1 to be 'gauge two'", records)
+	}
+
+	_, err = ReadStringTable(f, "/table", StringTableOptions{Headers: []string{"id", "name"}})
+	if !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("2 headers for 3 columns: got %v, want ErrDatatypeMismatch", err)
+	}
+}
+
+func TestReadStringTableInto(t *testing.T) {
+	f := stringTableFixture(t)
+	options := StringTableOptions{HeaderAttribute: "columns"}
+
+	type gauge struct {
+		ID     string `hdf:"id" strlen:"4"`
+		Name   string `hdf:"name" strlen:"9"`
+		Status string `hdf:"status"`
+	}
+	var gauges []gauge
+	if err := ReadStringTableInto(f, "/table", options, &gauges); err != nil {
+		t.Fatal(err)
+	}
+	want := []gauge{{"AB1", "gauge one", "ok"}, {"CD22", "gauge two", "down"}}
+	if !reflect.DeepEqual(gauges, want) {
+		t.Errorf("got %+v, want %+v", gauges, want)
+	}
+
+	var wide []struct {
+		ID string `hdf:"id" strlen:"8"`
+	}
+	if err := ReadStringTableInto(f, "/table", options, &wide); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("strlen 8 for a 4 byte column: got %v, want ErrDatatypeMismatch", err)
+	}
+	var missing []struct {
+		Owner string `hdf:"owner"`
+	}
+	if err := ReadStringTableInto(f, "/table", options, &missing); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("field bound to a missing column: got %v, want ErrDatatypeMismatch", err)
+	}
+}
+
+func TestReadVarLenStringTable(t *testing.T) {
+	path := varLenFixture(t, "/notes", []string{"a", "first note", "b", "second"}, []uint{2, 2})
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := ReadStringTable(f, "/notes", StringTableOptions{Headers: []string{"key", "note"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{{"key": "a", "note": "first note"}, {"key": "b", "note": "second"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %v, want %v", records, want)
+	}
+}
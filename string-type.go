@@ -0,0 +1,188 @@
+package hdf5utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// StrPad is the padding of fixed length strings, matching the H5T_str_t values
+type StrPad int
+
+const (
+	StrNullTerm StrPad = iota //terminated by a null, H5T_STR_NULLTERM
+	StrNullPad                //padded with nulls, H5T_STR_NULLPAD
+	StrSpacePad               //padded with spaces, H5T_STR_SPACEPAD
+)
+
+// StrCharset is the character set of strings, matching the H5T_cset_t values
+type StrCharset int
+
+const (
+	CharsetASCII StrCharset = iota
+	CharsetUTF8
+)
+
+// StringFormat describes how fixed length strings are stored
+type StringFormat struct {
+	Pad     StrPad
+	Charset StrCharset
+}
+
+// Decode converts a fixed length string field to a Go string, removing the padding.
+// UTF-8 strings cut short by the field width lose the partial character and invalid
+// sequences are replaced with U+FFFD.  ASCII strings are returned byte for byte.
+func (f StringFormat) Decode(b []byte) string {
+	switch f.Pad {
+	case StrNullTerm:
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+	case StrNullPad:
+		b = bytes.TrimRight(b, "\x00")
+	case StrSpacePad:
+		b = bytes.TrimRight(b, " ")
+	}
+	if f.Charset != CharsetUTF8 {
+		return string(b)
+	}
+	if len(b) > 0 {
+		if i := lastRuneStart(b); !utf8.FullRune(b[i:]) {
+			b = b[:i]
+		}
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return strings.ToValidUTF8(string(b), "\uFFFD")
+}
+
+// lastRuneStart returns the index of the byte starting the last (possibly partial) UTF-8 sequence
+func lastRuneStart(b []byte) int {
+	i := len(b) - 1
+	for i > 0 && len(b)-i < utf8.UTFMax && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	return i
+}
+
+// detectStrSizes reads the column byte widths of a fixed-length string table from the
+// dataset datatype.  1-D string datasets have a single column, 2-D string datasets have
+// one column of the string width per element of the second dimension and compound
+// datasets of strings have one column per member.
+func detectStrSizes(dset *hdf5.Dataset, dims []uint) (HdfStrSet, error) {
+	dtype, err := dset.Datatype()
+	if err != nil {
+		return HdfStrSet{}, err
+	}
+	defer dtype.Close()
+
+	switch dtype.Class() {
+	case hdf5.T_STRING:
+		if isVarLenString(dtype.ID()) {
+			return HdfStrSet{}, fmt.Errorf("variable length strings do not have fixed sizes: %w", ErrUnsupportedDatatype)
+		}
+		cols := 1
+		switch len(dims) {
+		case 1:
+		case 2:
+			cols = int(dims[1])
+		default:
+			return HdfStrSet{}, fmt.Errorf("string tables require a 1-D or 2-D dataset, dataset has %d dimensions: %w", len(dims), ErrUnsupportedDatatype)
+		}
+		format, err := stringFormat(dtype.ID())
+		if err != nil {
+			return HdfStrSet{}, err
+		}
+		sizes := make([]int, cols)
+		formats := make([]StringFormat, cols)
+		for i := range sizes {
+			sizes[i] = int(dtype.Size())
+			formats[i] = format
+		}
+		strset := NewHdfStrSet(sizes...)
+		strset.formats = formats
+		return strset, nil
+
+	case hdf5.T_COMPOUND:
+		if len(dims) != 1 {
+			return HdfStrSet{}, fmt.Errorf("compound string tables require a 1-D dataset, dataset has %d dimensions: %w", len(dims), ErrUnsupportedDatatype)
+		}
+		ctype := hdf5.CompoundType{*dtype}
+		sizes := make([]int, ctype.NMembers())
+		formats := make([]StringFormat, len(sizes))
+		for i := range sizes {
+			if ctype.MemberClass(i) != hdf5.T_STRING {
+				return HdfStrSet{}, fmt.Errorf("compound member '%s' is not a string: %w", ctype.MemberName(i), ErrUnsupportedDatatype)
+			}
+			mtype, err := ctype.MemberType(i)
+			if err != nil {
+				return HdfStrSet{}, err
+			}
+			sizes[i] = int(mtype.Size())
+			formats[i], err = stringFormat(mtype.ID())
+			mtype.Close()
+			if err != nil {
+				return HdfStrSet{}, err
+			}
+		}
+		strset := NewHdfStrSet(sizes...)
+		strset.formats = formats
+		return strset, nil
+
+	default:
+		return HdfStrSet{}, fmt.Errorf("dataset is not a string dataset: %w", ErrUnsupportedDatatype)
+	}
+}
+
+// datasetIsVarLenString reports whether the dataset holds variable length strings
+func datasetIsVarLenString(dset *hdf5.Dataset) (bool, error) {
+	dtype, err := dset.Datatype()
+	if err != nil {
+		return false, newHdfError("read datatype", dset.Name(), nil, err)
+	}
+	defer dtype.Close()
+	return dtype.Class() == hdf5.T_STRING && isVarLenString(dtype.ID()), nil
+}
+
+// setStrings copies strs into dest, which must be a *[]string
+func setStrings(dest interface{}, strs *[]string) error {
+	d, ok := dest.(*[]string)
+	if !ok {
+		return fmt.Errorf("variable length strings must be read into a *[]string, not %T: %w", dest, ErrUnsupportedDatatype)
+	}
+	*d = *strs
+	return nil
+}
+
+// resolveStrSizes returns the string sizes of the dataset, checking any sizes supplied
+// by the caller against the file
+func resolveStrSizes(dset *hdf5.Dataset, dims []uint, supplied HdfStrSet) (HdfStrSet, error) {
+	detected, err := detectStrSizes(dset, dims)
+	if err != nil {
+		return HdfStrSet{}, newHdfError("read string sizes", dset.Name(), nil, err)
+	}
+	if len(supplied.Cols()) == 0 {
+		return detected, nil
+	}
+	if !equalInts(supplied.Cols(), detected.Cols()) {
+		return HdfStrSet{}, newHdfError("read string sizes", dset.Name(), ErrDatatypeMismatch,
+			fmt.Errorf("string sizes %v do not match the dataset string sizes %v", supplied.Cols(), detected.Cols()))
+	}
+	return detected, nil
+}
+
+func equalInts(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package hdf5utils
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectStrSizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tables.h5")
+	for datapath, table := range map[string][][]string{
+		"/uniform": {{"ab", "cd"}, {"e", "f"}},
+		"/mixed":   {{"ab", "cde"}, {"f", "g"}},
+	} {
+		w, err := NewHdfWriterSync(datapath, HdfWriteOptions{Dtype: reflect.String, Filepath: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.WriteFrom(table)
+		w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	values := []float64{1, 2}
+	w, err := NewHdfWriterSync("/values", HdfWriteOptions{Dtype: reflect.Float64, Filepath: path, Dims: []uint{2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.WriteFrom(&values)
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	open := func(datapath string, strsizes HdfStrSet) (HdfStrSet, error) {
+		reader, err := NewHdfReaderSync(datapath, HdfReadOptions{Dtype: reflect.String, Strsizes: strsizes, Filepath: path, Pool: NewFilePool(0)})
+		if err != nil {
+			return HdfStrSet{}, err
+		}
+		defer reader.Close()
+		return reader.(StrSizer).StrSizes(), nil
+	}
+	for datapath, want := range map[string][]int{"/uniform": {2, 2}, "/mixed": {2, 3}} {
+		sizes, err := open(datapath, HdfStrSet{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sizes.Cols(), want) {
+			t.Errorf("%s: got sizes %v, want %v", datapath, sizes.Cols(), want)
+		}
+	}
+	if _, err := open("/mixed", NewHdfStrSet(2, 3)); err != nil {
+		t.Errorf("matching sizes: %v", err)
+	}
+	if _, err := open("/mixed", NewHdfStrSet(4, 4)); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("mismatched sizes: got %v, want ErrDatatypeMismatch", err)
+	}
+	if _, err := open("/values", HdfStrSet{}); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("float dataset: got %v, want ErrUnsupportedDatatype", err)
+	}
+}
+
+func TestStringFormatDecode(t *testing.T) {
+	tests := []struct {
+		format StringFormat
+		field  string
+		want   string
+	}{
+		{StringFormat{StrNullTerm, CharsetASCII}, "abc\x00xyz", "abc"},
+		{StringFormat{StrNullTerm, CharsetASCII}, "abc  \x00", "abc  "},
+		{StringFormat{StrNullTerm, CharsetASCII}, " abc\x00\x00", " abc"},
+		{StringFormat{StrNullPad, CharsetASCII}, "abc\x00\x00", "abc"},
+		{StringFormat{StrNullPad, CharsetASCII}, "abc  \x00", "abc  "},
+		{StringFormat{StrSpacePad, CharsetASCII}, "abc   ", "abc"},
+		{StringFormat{StrSpacePad, CharsetUTF8}, "d\xc3\xa9j\xc3", "déj"},
+		{StringFormat{StrNullTerm, CharsetUTF8}, "a\xffb\x00", "a�b"},
+	}
+	for i, tc := range tests {
+		if got := tc.format.Decode([]byte(tc.field)); got != tc.want {
+			t.Errorf("case %d: got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestHdfStrSetDecode(t *testing.T) {
+	field := []byte(" abc  ")
+	supplied := NewHdfStrSet(6)
+	if got := supplied.decode(0, field, false); got != "abc" {
+		t.Errorf("unknown format: got %q, want %q", got, "abc")
+	}
+	if got := supplied.decode(0, field, true); got != " abc  " {
+		t.Errorf("raw: got %q", got)
+	}
+	detected := HdfStrSet{sizes: []int{6}, buffersize: 6, formats: []StringFormat{{StrSpacePad, CharsetASCII}}}
+	if got := detected.decode(0, field, false); got != " abc" {
+		t.Errorf("space padded: got %q, want %q", got, " abc")
+	}
+}
@@ -0,0 +1,147 @@
+package hdf5utils
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Element is the set of Go types a TypedDataset can hold.  Strings read fixed or
+// variable length string datasets, bool reads HDF5 enum booleans.
+type Element interface {
+	int8 | int16 | int32 | int64 | uint8 | uint16 | uint32 | uint64 | float32 | float64 | bool | string
+}
+
+// TypedDataset reads a dataset into slices of T.  T is checked against the datatype
+// in the file when the dataset is opened.  The read methods return ([]T, error) rather
+// than []T so failed reads and invalid indices are reported instead of panicking.
+type TypedDataset[T Element] struct {
+	datapath string
+	dset     *HdfDataset
+}
+
+// NewTypedDataset opens a dataset for reading as T.  options.Dtype is set from T and
+// must be empty or match it.
+func NewTypedDataset[T Element](datapath string, options HdfReadOptions) (*TypedDataset[T], error) {
+	var zero T
+	kind := reflect.TypeOf(zero).Kind()
+	if options.Dtype != reflect.Invalid && options.Dtype != kind {
+		return nil, newHdfError("open dataset", datapath, ErrDatatypeMismatch, fmt.Errorf("option Dtype %s does not match the element type %s", options.Dtype, kind))
+	}
+	options.Dtype = kind
+	dset, err := NewHdfDataset(datapath, options)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedDataset[T]{datapath, dset}, nil
+}
+
+func (t *TypedDataset[T]) Close() {
+	t.dset.Close()
+}
+
+// Dims returns the dimensions of the dataset, not those of the last subset read
+func (t *TypedDataset[T]) Dims() []uint {
+	if t.subset() {
+		return t.dset.Data.DsetDims
+	}
+	return t.dset.Dims()
+}
+
+// Dataset returns the underlying untyped dataset
+func (t *TypedDataset[T]) Dataset() *HdfDataset {
+	return t.dset
+}
+
+// Read reads the entire dataset as a flat row major slice
+func (t *TypedDataset[T]) Read() ([]T, error) {
+	err := t.dset.Read()
+	if err != nil {
+		return nil, err
+	}
+	return t.values()
+}
+
+// ReadSubset reads the inclusive row and column ranges as a flat row major slice
+func (t *TypedDataset[T]) ReadSubset(rowrange []int, colrange []int) ([]T, error) {
+	err := t.dset.ReadSubset(rowrange, colrange)
+	if err != nil {
+		return nil, err
+	}
+	return t.values()
+}
+
+// Row returns row i of the dataset, reading the dataset first if it has not been read
+// or only a subset was read
+func (t *TypedDataset[T]) Row(i int) ([]T, error) {
+	err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= t.dset.Rows() {
+		return nil, newHdfError("read row", t.datapath, ErrOutOfRange, fmt.Errorf("row %d of %d", i, t.dset.Rows()))
+	}
+	row := []T{}
+	err = t.dset.ReadRow(i, &row)
+	return row, err
+}
+
+// Column returns column j of the dataset, reading the dataset first if it has not been
+// read or only a subset was read
+func (t *TypedDataset[T]) Column(j int) ([]T, error) {
+	err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	if j < 0 || j >= t.cols() {
+		return nil, newHdfError("read column", t.datapath, ErrOutOfRange, fmt.Errorf("column %d of %d", j, t.cols()))
+	}
+	col := []T{}
+	err = t.dset.ReadColumn(j, &col)
+	return col, err
+}
+
+// load reads the entire dataset unless it is already in memory or read incrementally
+func (t *TypedDataset[T]) load() error {
+	if (t.dset.Data != nil && !t.subset()) || t.dset.options.IncrementalRead {
+		return nil
+	}
+	return t.dset.Read()
+}
+
+// subset reports whether the buffer holds a subset read with ReadSubset
+func (t *TypedDataset[T]) subset() bool {
+	data := t.dset.Data
+	return data != nil && len(data.DsetDims) > 0 && !equalDims(data.Dims, data.DsetDims)
+}
+
+// cols returns the number of columns, the string columns of fixed length string tables
+func (t *TypedDataset[T]) cols() int {
+	if t.dset.Data != nil && t.dset.options.Dtype == reflect.String {
+		if _, ok := t.dset.Data.Buffer.(*[]uint8); ok {
+			return len(t.dset.options.Strsizes.Cols())
+		}
+	}
+	return t.dset.Cols()
+}
+
+// values returns the dataset buffer as []T.  Fixed length string tables are
+// decoded row by row.
+func (t *TypedDataset[T]) values() ([]T, error) {
+	if buf, ok := t.dset.Data.Buffer.(*[]T); ok {
+		return *buf, nil
+	}
+	if _, ok := t.dset.Data.Buffer.(*[]uint8); ok && t.dset.options.Dtype == reflect.String {
+		rows := int(t.dset.Data.Dims[0])
+		values := make([]T, 0, rows*len(t.dset.options.Strsizes.Cols()))
+		for i := 0; i < rows; i++ {
+			row := []T{}
+			err := t.dset.readVectorString(true, i, &row)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, row...)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("dataset buffer of type %T cannot be read as %T: %w", t.dset.Data.Buffer, []T{}, ErrDatatypeMismatch)
+}
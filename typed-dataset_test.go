@@ -0,0 +1,159 @@
+package hdf5utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewTypedDatasetChecksType(t *testing.T) {
+	values := []float64{1, 2, 3}
+	path := fixtureFile(t, "/values", &values, []uint{3})
+	options := HdfReadOptions{Filepath: path, Pool: NewFilePool(0)}
+
+	if _, err := NewTypedDataset[int16]("/values", options); !errors.Is(err, ErrLossyConversion) {
+		t.Errorf("float64 as int16: got %v, want ErrLossyConversion", err)
+	}
+	if _, err := NewTypedDataset[bool]("/values", options); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("float64 as bool: got %v, want ErrUnsupportedDatatype", err)
+	}
+	options.Dtype = reflect.Float32
+	if _, err := NewTypedDataset[float64]("/values", options); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("Dtype float32 with float64 elements: got %v, want ErrDatatypeMismatch", err)
+	}
+
+	bytes := []uint8{0, 1, 2}
+	path = fixtureFile(t, "/bytes", &bytes, []uint{3})
+	if _, err := NewTypedDataset[bool]("/bytes", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)}); !errors.Is(err, ErrDatatypeMismatch) {
+		t.Errorf("uint8 as bool: got %v, want ErrDatatypeMismatch", err)
+	}
+}
+
+func TestTypedDatasetRead(t *testing.T) {
+	values := []int32{
+		0, 1, 2, 3,
+		10, 11, 12, 13,
+		20, 21, 22, 23,
+	}
+	path := fixtureFile(t, "/grid", &values, []uint{3, 4})
+	dset, err := NewTypedDataset[int32]("/grid", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+
+	row, err := dset.Row(1)
+	if err != nil || !reflect.DeepEqual(row, []int32{10, 11, 12, 13}) {
+		t.Errorf("row 1: got %v, %v", row, err)
+	}
+	all, err := dset.Read()
+	if err != nil || !reflect.DeepEqual(all, values) {
+		t.Errorf("read: got %v, %v", all, err)
+	}
+
+	subset, err := dset.ReadSubset([]int{1, 2}, []int{2, 3})
+	if err != nil || !reflect.DeepEqual(subset, []int32{12, 13, 22, 23}) {
+		t.Errorf("subset: got %v, %v", subset, err)
+	}
+	if dims := dset.Dims(); !reflect.DeepEqual(dims, []uint{3, 4}) {
+		t.Errorf("got dims %v after a subset read, want the dataset dims [3 4]", dims)
+	}
+	//rows and columns index the whole dataset after a subset read
+	row, err = dset.Row(0)
+	if err != nil || !reflect.DeepEqual(row, []int32{0, 1, 2, 3}) {
+		t.Errorf("row 0 after a subset read: got %v, %v", row, err)
+	}
+	if _, err := dset.ReadSubset([]int{0, 0}, []int{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	col, err := dset.Column(3)
+	if err != nil || !reflect.DeepEqual(col, []int32{3, 13, 23}) {
+		t.Errorf("column 3 after a subset read: got %v, %v", col, err)
+	}
+}
+
+func TestTypedDatasetSubsetThenRow(t *testing.T) {
+	values := []float32{
+		0, 1, 2,
+		10, 11, 12,
+		20, 21, 22,
+		30, 31, 32,
+	}
+	path := fixtureFile(t, "/grid", &values, []uint{4, 3})
+	dset, err := NewTypedDataset[float32]("/grid", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+
+	subset, err := dset.ReadSubset([]int{1, 1}, []int{1, 2})
+	if err != nil || !reflect.DeepEqual(subset, []float32{11, 12}) {
+		t.Fatalf("subset: got %v, %v", subset, err)
+	}
+	if dims := dset.Dims(); !reflect.DeepEqual(dims, []uint{4, 3}) {
+		t.Errorf("got dims %v after a subset read, want the dataset dims [4 3]", dims)
+	}
+	//row 3 lies outside the subset, so the whole dataset is read again
+	row, err := dset.Row(3)
+	if err != nil || !reflect.DeepEqual(row, []float32{30, 31, 32}) {
+		t.Errorf("row 3 after a subset read: got %v, %v", row, err)
+	}
+	if dims := dset.Dataset().Data.Dims; !reflect.DeepEqual(dims, []uint{4, 3}) {
+		t.Errorf("got buffer dims %v after Row, want the whole dataset [4 3]", dims)
+	}
+	col, err := dset.Column(0)
+	if err != nil || !reflect.DeepEqual(col, []float32{0, 10, 20, 30}) {
+		t.Errorf("column 0: got %v, %v", col, err)
+	}
+}
+
+func TestTypedDatasetOutOfRange(t *testing.T) {
+	values := []int32{
+		0, 1, 2, 3,
+		10, 11, 12, 13,
+		20, 21, 22, 23,
+	}
+	path := fixtureFile(t, "/grid", &values, []uint{3, 4})
+	dset, err := NewTypedDataset[int32]("/grid", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+
+	check := func(stage string) {
+		t.Helper()
+		for _, i := range []int{-1, 3} {
+			if _, err := dset.Row(i); !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("%s: row %d: got %v, want ErrOutOfRange", stage, i, err)
+			}
+		}
+		for _, j := range []int{-1, 4} {
+			if _, err := dset.Column(j); !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("%s: column %d: got %v, want ErrOutOfRange", stage, j, err)
+			}
+		}
+	}
+	check("before a read")
+	if _, err := dset.ReadSubset([]int{0, 0}, []int{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	check("after a subset read")
+	//indices past the subset but inside the dataset are valid
+	if row, err := dset.Row(2); err != nil || !reflect.DeepEqual(row, []int32{20, 21, 22, 23}) {
+		t.Errorf("row 2 after a subset read: got %v, %v", row, err)
+	}
+
+	vector := []float64{1, 2, 3}
+	path = fixtureFile(t, "/vector", &vector, []uint{3})
+	vdset, err := NewTypedDataset[float64]("/vector", HdfReadOptions{Filepath: path, Pool: NewFilePool(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vdset.Close()
+	if _, err := vdset.Column(1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("column 1 of a vector: got %v, want ErrOutOfRange", err)
+	}
+	if _, err := vdset.Row(3); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("row 3 of a vector: got %v, want ErrOutOfRange", err)
+	}
+}
@@ -0,0 +1,566 @@
+package hdf5utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+///////////////////////////////////////////////////////
+///////////HDF Writer Interface///////////////
+
+type HdfWriter interface {
+	Dims() []uint
+	Write(data *HdfData) error
+	WriteFrom(src interface{}) error
+	WriteSubset(data *HdfData, rowrange []int, colrange []int) error
+	WriteSubsetFrom(src interface{}, rowrange []int, colrange []int) error
+	Append(src interface{}) error
+	Close()
+}
+
+type HdfWriteOptions struct {
+	Dtype      reflect.Kind //inferred from the source slice when the dataset is created on first write
+	Strsizes   HdfStrSet
+	Dims       []uint //dimensions used to create the dataset if it does not exist, on the first write when Dtype is inferred. For strings only the row count is used
+	Extendable bool   //create a chunked dataset with an unlimited number of rows that can grow with Append
+	Create     HdfCreateOptions
+	Filepath   string //local file, created if it does not exist
+	File       *hdf5.File
+}
+
+// go types used to build native datatypes for written datasets
+var kindTypes map[reflect.Kind]reflect.Type = map[reflect.Kind]reflect.Type{
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Bool:    reflect.TypeOf(false),
+}
+
+///////////////////////////////////////////////////////
+/////////////////////HDF Writer Sync//////////////////
+
+type HdfWriterSync struct {
+	file       *hdf5.File
+	dset       *hdf5.Dataset
+	datapath   string
+	dtype      reflect.Kind
+	dims       []uint //dimension of the target dataset
+	maxdims    []uint
+	createDims []uint //dimensions of a dataset created on the first write, once its kind is inferred
+	strsizes   HdfStrSet
+	extendable bool
+	create     HdfCreateOptions
+	fileCloser bool
+}
+
+func NewHdfWriterSync(datapath string, options HdfWriteOptions) (HdfWriter, error) {
+	var err error
+	fileCloser := false
+	f := options.File
+	if f == nil {
+		f, err = openOrCreateFile(options.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		fileCloser = true
+	}
+
+	writer := &HdfWriterSync{
+		file:       f,
+		datapath:   datapath,
+		dtype:      options.Dtype,
+		strsizes:   options.Strsizes,
+		extendable: options.Extendable,
+		create:     options.Create,
+		fileCloser: fileCloser,
+	}
+
+	if f.LinkExists(datapath) {
+		err = writer.open()
+	} else if len(options.Dims) > 0 && options.Dtype != reflect.Invalid {
+		err = writer.createDataset(options.Dims)
+	} else {
+		writer.createDims = options.Dims
+	}
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+func openOrCreateFile(path string) (*hdf5.File, error) {
+	if _, err := os.Stat(path); err == nil {
+		return OpenFileRW(path)
+	}
+	return CreateFile(path, CreateExclusive)
+}
+
+func (h *HdfWriterSync) Close() {
+	if h.dset != nil {
+		defer h.dset.Close()
+	}
+	if h.fileCloser {
+		defer h.file.Close()
+	}
+}
+
+func (h *HdfWriterSync) Dims() []uint {
+	return h.dims
+}
+
+func (h *HdfWriterSync) Write(data *HdfData) error {
+	if h.dset == nil {
+		if h.dtype == reflect.Invalid {
+			h.dtype = sliceKind(data.Buffer)
+		}
+		err := h.createDataset(data.Dims)
+		if err != nil {
+			return err
+		}
+	}
+	return h.WriteFrom(data.Buffer)
+}
+
+func (h *HdfWriterSync) WriteFrom(src interface{}) error {
+	if h.dset == nil {
+		err := h.createFrom(src)
+		if err != nil {
+			return err
+		}
+	}
+	buf, err := h.buffer(src, h.dims[0], h.strsizes.Cols(), arraySize(h.dims))
+	if err != nil {
+		return err
+	}
+	return h.dset.Write(buf)
+}
+
+func (h *HdfWriterSync) WriteSubset(data *HdfData, rowrange []int, colrange []int) error {
+	return h.WriteSubsetFrom(data.Buffer, rowrange, colrange)
+}
+
+func (h *HdfWriterSync) WriteSubsetFrom(src interface{}, rowrange []int, colrange []int) error {
+	if h.dset == nil {
+		return newHdfError("write subset", h.datapath, ErrDatasetNotFound, errors.New("the dataset must be created before writing a subset"))
+	}
+	filespace := h.dset.Space()
+	defer filespace.Close()
+
+	widths := h.strsizes.Cols()
+	subsetCols := colrange
+	if h.isCompoundStrings() {
+		//compound string tables are 1-D so only full rows can be written
+		if len(colrange) != 2 || colrange[0] != 0 || colrange[1] != len(widths)-1 {
+			return newHdfError("write subset", h.datapath, ErrOutOfRange, errors.New("string tables with mixed column widths can only be written by full rows"))
+		}
+		subsetCols = []int{0, 0}
+	} else if h.dtype == reflect.String && len(h.dims) > 1 && len(colrange) == 2 && colrange[0] >= 0 && colrange[1] < len(widths) && colrange[0] <= colrange[1] {
+		widths = widths[colrange[0] : colrange[1]+1]
+	}
+
+	memspace, dims, err := selectSubset(filespace, h.dims, h.datapath, rowrange, subsetCols)
+	if err != nil {
+		return err
+	}
+	defer memspace.Close()
+
+	buf, err := h.buffer(src, dims[0], widths, arraySize(dims))
+	if err != nil {
+		return err
+	}
+	return h.dset.WriteSubset(buf, memspace, filespace)
+}
+
+func (h *HdfWriterSync) open() error {
+	if h.dtype == reflect.Invalid {
+		return newHdfError("open dataset", h.datapath, ErrUnsupportedDatatype, errors.New("Dtype is required when writing to an existing dataset"))
+	}
+	dset, err := openDataset(h.file, h.datapath)
+	if err != nil {
+		return err
+	}
+	h.dset = dset
+	space := dset.Space()
+	defer space.Close()
+	dims, maxdims, err := space.SimpleExtentDims()
+	if err != nil {
+		return newHdfError("read dimensions", h.datapath, nil, err)
+	}
+	h.dims = dims
+	h.maxdims = maxdims
+	return h.checkDatatype()
+}
+
+// checkDatatype verifies an existing dataset stores values in the native layout of the writer's Go type
+// since the dataset datatype is used as the memory datatype when writing
+func (h *HdfWriterSync) checkDatatype() error {
+	filetype, err := h.dset.Datatype()
+	if err != nil {
+		return err
+	}
+	defer filetype.Close()
+	memtype, err := h.datatype()
+	if err != nil {
+		return err
+	}
+	defer memtype.Close()
+	if h.dtype == reflect.String {
+		//padding and character set may differ for strings written by other tools
+		if filetype.Class() != memtype.Class() || filetype.Size() != memtype.Size() {
+			return newHdfError("open dataset", h.datapath, ErrUnsupportedDatatype, errors.New("dataset string layout does not match the string sizes"))
+		}
+		return nil
+	}
+	if !filetype.Equal(memtype) {
+		return newHdfError("open dataset", h.datapath, ErrUnsupportedDatatype, fmt.Errorf("dataset datatype does not match %s", h.dtype))
+	}
+	return nil
+}
+
+func (h *HdfWriterSync) createFrom(src interface{}) error {
+	if h.dtype == reflect.Invalid {
+		h.dtype = sliceKind(src)
+	}
+	if h.dtype == reflect.String {
+		rows, ok := src.([][]string)
+		if !ok {
+			return newHdfError("create dataset", h.datapath, nil, errors.New("Dims are required to create a string dataset from a byte buffer"))
+		}
+		if len(h.strsizes.Cols()) == 0 {
+			h.strsizes = NewHdfStrSet(columnWidths(rows)...)
+		}
+		return h.createDataset([]uint{uint(len(rows))})
+	}
+	if len(h.createDims) > 0 {
+		return h.createDataset(h.createDims)
+	}
+	n := bufferLen(src)
+	if n < 0 {
+		return newHdfError("create dataset", h.datapath, ErrUnsupportedDatatype, fmt.Errorf("source of type %T is not a slice", src))
+	}
+	return h.createDataset([]uint{uint(n)})
+}
+
+func (h *HdfWriterSync) createDataset(dims []uint) error {
+	if len(dims) == 0 {
+		return newHdfError("create dataset", h.datapath, nil, errors.New("missing dataset dimensions"))
+	}
+	dtype, err := h.datatype()
+	if err != nil {
+		return err
+	}
+	defer dtype.Close()
+
+	if h.dtype == reflect.String {
+		dims = h.stringDims(dims[0])
+	}
+	maxdims := append([]uint{}, dims...)
+	if h.extendable {
+		maxdims[0] = unlimitedDim
+	}
+	space, err := hdf5.CreateSimpleDataspace(dims, maxdims)
+	if err != nil {
+		return err
+	}
+	defer space.Close()
+
+	dcpl, err := datasetCreatePropList(h.create, dims, maxdims, dtype, h.dtype)
+	if err != nil {
+		return newHdfError("create dataset", h.datapath, nil, err)
+	}
+	defer dcpl.Close()
+
+	err = createParentGroups(h.file, h.datapath)
+	if err != nil {
+		return err
+	}
+	dset, err := h.file.CreateDatasetWith(h.datapath, dtype, space, dcpl)
+	if err != nil {
+		return newHdfError("create dataset", h.datapath, nil, err)
+	}
+	h.dset = dset
+	h.dims = dims
+	h.maxdims = maxdims
+	return nil
+}
+
+// Append extends the dataset by the rows in src and writes them to the new rows.
+// Numeric sources are flattened row-major slices, string sources are [][]string or packed bytes.
+func (h *HdfWriterSync) Append(src interface{}) error {
+	if h.dset == nil {
+		if len(h.createDims) == 0 {
+			return h.WriteFrom(src)
+		}
+		if err := h.createFrom(src); err != nil {
+			return err
+		}
+	}
+	if h.maxdims[0] != unlimitedDim && h.maxdims[0] == h.dims[0] {
+		return newHdfError("append", h.datapath, ErrOutOfRange, errors.New("dataset does not have an unlimited row dimension"))
+	}
+
+	rows, err := h.rowCount(src)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return nil
+	}
+	//validate the source before the dataset is extended so a bad source does not leave fill value rows
+	buf, err := h.buffer(src, rows, h.strsizes.Cols(), rows*arraySize(h.dims[1:]))
+	if err != nil {
+		return newHdfError("append", h.datapath, nil, err)
+	}
+	olddims := h.dims
+	newdims := append([]uint{}, h.dims...)
+	newdims[0] += rows
+	if h.maxdims[0] != unlimitedDim && newdims[0] > h.maxdims[0] {
+		return newHdfError("append", h.datapath, ErrOutOfRange, fmt.Errorf("%d rows exceeds the maximum dataset size of %d", newdims[0], h.maxdims[0]))
+	}
+	err = setExtent(h.dset.ID(), newdims)
+	if err != nil {
+		return newHdfError("append", h.datapath, nil, err)
+	}
+	h.dims = newdims
+
+	colrange := []int{0, 0}
+	if len(h.dims) > 1 {
+		colrange[1] = int(h.dims[1]) - 1
+	} else if h.isCompoundStrings() {
+		colrange[1] = len(h.strsizes.Cols()) - 1
+	}
+	err = h.WriteSubsetFrom(buf, []int{int(olddims[0]), int(newdims[0]) - 1}, colrange)
+	if err != nil {
+		//shrink the dataset back to the rows written before the failed append
+		if setExtent(h.dset.ID(), olddims) == nil {
+			h.dims = olddims
+		}
+		return err
+	}
+	return nil
+}
+
+// rowCount returns the number of dataset rows contained in src
+func (h *HdfWriterSync) rowCount(src interface{}) (uint, error) {
+	if table, ok := src.([][]string); ok {
+		return uint(len(table)), nil
+	}
+	n := bufferLen(src)
+	if n < 0 {
+		return 0, fmt.Errorf("source of type %T is not a slice: %w", src, ErrUnsupportedDatatype)
+	}
+	rowsize := 1
+	if h.dtype == reflect.String {
+		rowsize = h.strsizes.RowSize()
+	} else if len(h.dims) > 1 {
+		rowsize = int(arraySize(h.dims[1:]))
+	}
+	if rowsize == 0 || n%rowsize != 0 {
+		return 0, fmt.Errorf("source length %d is not a multiple of the row size %d", n, rowsize)
+	}
+	return uint(n / rowsize), nil
+}
+
+// datatype builds the native file datatype for the writer's Go type
+func (h *HdfWriterSync) datatype() (*hdf5.Datatype, error) {
+	if h.dtype == reflect.String {
+		return stringTableDatatype(h.strsizes)
+	}
+	if h.dtype == reflect.Bool {
+		return boolDatatype()
+	}
+	t, ok := kindTypes[h.dtype]
+	if !ok {
+		return nil, newHdfError("create datatype", h.datapath, ErrUnsupportedDatatype, fmt.Errorf("kind %s", h.dtype))
+	}
+	return hdf5.NewDataTypeFromType(t)
+}
+
+// string tables with uniform widths are stored as a 2-D dataset of fixed length strings
+// while mixed widths are stored as a 1-D dataset of compound string records
+func (h *HdfWriterSync) stringDims(rows uint) []uint {
+	cols := len(h.strsizes.Cols())
+	if cols <= 1 || h.isCompoundStrings() {
+		return []uint{rows}
+	}
+	return []uint{rows, uint(cols)}
+}
+
+func (h *HdfWriterSync) isCompoundStrings() bool {
+	return h.dtype == reflect.String && !uniformWidths(h.strsizes.Cols())
+}
+
+func stringTableDatatype(strsizes HdfStrSet) (*hdf5.Datatype, error) {
+	widths := strsizes.Cols()
+	if len(widths) == 0 {
+		return nil, fmt.Errorf("string sizes are required for string datasets: %w", ErrUnsupportedDatatype)
+	}
+	if uniformWidths(widths) {
+		return stringDatatype(widths[0])
+	}
+	ctype, err := hdf5.NewCompoundType(strsizes.RowSize())
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	for i, w := range widths {
+		member, err := stringDatatype(w)
+		if err != nil {
+			ctype.Close()
+			return nil, err
+		}
+		err = ctype.Insert(strconv.Itoa(i), offset, member)
+		member.Close()
+		if err != nil {
+			ctype.Close()
+			return nil, err
+		}
+		offset += w
+	}
+	return &ctype.Datatype, nil
+}
+
+func stringDatatype(size int) (*hdf5.Datatype, error) {
+	dtype, err := hdf5.T_C_S1.Copy()
+	if err != nil {
+		return nil, err
+	}
+	err = dtype.SetSize(size)
+	if err != nil {
+		dtype.Close()
+		return nil, err
+	}
+	return dtype, nil
+}
+
+func uniformWidths(widths []int) bool {
+	for _, w := range widths {
+		if w != widths[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// buffer validates the source against the size of the selection, packing
+// [][]string rows into the fixed width byte layout of the string table
+func (h *HdfWriterSync) buffer(src interface{}, rows uint, widths []int, size uint) (interface{}, error) {
+	if h.dtype == reflect.String {
+		rowsize := 0
+		for _, w := range widths {
+			rowsize += w
+		}
+		if table, ok := src.([][]string); ok {
+			if len(table) != int(rows) {
+				return nil, fmt.Errorf("expected %d rows but received %d", rows, len(table))
+			}
+			return packStrings(table, widths)
+		}
+		if n := bufferLen(src); n != int(rows)*rowsize {
+			return nil, fmt.Errorf("expected a string buffer of %d bytes but received %d", int(rows)*rowsize, n)
+		}
+		return src, nil
+	}
+	if kind := sliceKind(src); kind != h.dtype {
+		return nil, fmt.Errorf("source of type %T does not match dataset type %s: %w", src, h.dtype, ErrUnsupportedDatatype)
+	}
+	if n := bufferLen(src); n != int(size) {
+		return nil, fmt.Errorf("expected %d values but received %d", size, n)
+	}
+	return src, nil
+}
+
+func packStrings(table [][]string, widths []int) ([]byte, error) {
+	rowsize := 0
+	for _, w := range widths {
+		rowsize += w
+	}
+	buf := make([]byte, len(table)*rowsize)
+	offset := 0
+	for r, row := range table {
+		if len(row) != len(widths) {
+			return nil, fmt.Errorf("row %d has %d columns but the string table has %d", r, len(row), len(widths))
+		}
+		for c, v := range row {
+			if len(v) > widths[c] {
+				return nil, fmt.Errorf("value '%s' at row %d column %d exceeds the column width of %d", v, r, c, widths[c])
+			}
+			copy(buf[offset:offset+widths[c]], v)
+			offset += widths[c]
+		}
+	}
+	return buf, nil
+}
+
+// columnWidths returns the longest value in each column of a string table
+func columnWidths(table [][]string) []int {
+	widths := []int{}
+	for _, row := range table {
+		for c, v := range row {
+			if c >= len(widths) {
+				widths = append(widths, 1)
+			}
+			if len(v) > widths[c] {
+				widths[c] = len(v)
+			}
+		}
+	}
+	return widths
+}
+
+func createParentGroups(f *hdf5.File, datapath string) error {
+	parts := strings.Split(strings.Trim(datapath, "/"), "/")
+	path := ""
+	for _, p := range parts[:len(parts)-1] {
+		path = path + "/" + p
+		if f.LinkExists(path) {
+			continue
+		}
+		g, err := f.CreateGroup(path)
+		if err != nil {
+			return fmt.Errorf("unable to create group '%s': %s", path, err)
+		}
+		g.Close()
+	}
+	return nil
+}
+
+// sliceKind returns the element kind of a slice, array or pointer to either
+func sliceKind(src interface{}) reflect.Kind {
+	t := reflect.TypeOf(src)
+	if t == nil {
+		return reflect.Invalid
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return reflect.Invalid
+	}
+	return t.Elem().Kind()
+}
+
+func bufferLen(src interface{}) int {
+	if src == nil {
+		return -1
+	}
+	v := reflect.Indirect(reflect.ValueOf(src))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return -1
+	}
+	return v.Len()
+}
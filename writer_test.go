@@ -0,0 +1,213 @@
+package hdf5utils
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	hdf5 "github.com/usace/go-hdf5"
+)
+
+// fixtureFile writes src to datapath in a new file under the test temp dir and returns the file path
+func fixtureFile(t *testing.T, datapath string, src interface{}, dims []uint) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.h5")
+	w, err := NewHdfWriterSync(datapath, HdfWriteOptions{Dtype: sliceKind(src), Filepath: path, Dims: dims})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.WriteFrom(src); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newFixture creates a file under the test temp dir that is closed when the test ends
+func newFixture(t *testing.T, name string) *hdf5.File {
+	t.Helper()
+	f, err := CreateFile(filepath.Join(t.TempDir(), name), CreateExclusive)
+	must(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// must fails the test when building a fixture returns an error
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteFrom(t *testing.T) {
+	values := []float64{1, 2, 3, 4}
+	path := fixtureFile(t, "/results/values", &values, []uint{4})
+
+	w, err := NewHdfWriterSync("/results/values", HdfWriteOptions{Dtype: reflect.Float64, Filepath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFrom(&[]int32{1, 2, 3, 4}); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("write int32 values to a float64 dataset: got %v, want ErrUnsupportedDatatype", err)
+	}
+	if err := w.WriteFrom(&[]float64{1, 2}); err == nil {
+		t.Error("expected an error writing fewer values than the dataset holds")
+	}
+	w.Close()
+
+	dset, err := NewHdfDataset("/results/values", HdfReadOptions{Dtype: reflect.Float64, Filepath: path, ReadOnCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dset.Close()
+	if got := *dset.Data.Buffer.(*[]float64); !reflect.DeepEqual(got, values) {
+		t.Errorf("got %v, want %v", got, values)
+	}
+}
+
+func TestAppendRefresh(t *testing.T) {
+	f, err := CreateFile(filepath.Join(t.TempDir(), "series.h5"), CreateExclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := NewHdfWriterSync("/series", HdfWriteOptions{Dtype: reflect.Float64, Dims: []uint{0, 2}, Extendable: true, File: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	reader, err := NewHdfDataset("/series", HdfReadOptions{Dtype: reflect.Float64, File: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	if err := w.Append(&[]float64{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(&[]float64{5, 6}); err != nil {
+		t.Fatal(err)
+	}
+	if dims := w.Dims(); !reflect.DeepEqual(dims, []uint{3, 2}) {
+		t.Errorf("writer dims: got %v, want [3 2]", dims)
+	}
+
+	if err := reader.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if dims := reader.Dims(); !reflect.DeepEqual(dims, []uint{3, 2}) {
+		t.Errorf("reader dims after refresh: got %v, want [3 2]", dims)
+	}
+	if err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if got := *reader.Data.Buffer.(*[]float64); !reflect.DeepEqual(got, []float64{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("got %v after two appends", got)
+	}
+
+	if err := w.Append(&[]float64{7}); err == nil {
+		t.Error("expected an error appending a partial row")
+	}
+	if err := w.Append(&[]int32{7, 8}); !errors.Is(err, ErrUnsupportedDatatype) {
+		t.Errorf("append int32 values to a float64 dataset: got %v, want ErrUnsupportedDatatype", err)
+	}
+	if dims := w.Dims(); !reflect.DeepEqual(dims, []uint{3, 2}) {
+		t.Errorf("writer dims after a failed append: got %v, want [3 2]", dims)
+	}
+	if err := reader.Refresh(); err != nil || !reflect.DeepEqual(reader.Dims(), []uint{3, 2}) {
+		t.Errorf("a failed append extended the dataset to %v, %v", reader.Dims(), err)
+	}
+	fixed, err := NewHdfWriterSync("/fixed", HdfWriteOptions{Dtype: reflect.Float64, Dims: []uint{2}, File: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixed.Close()
+	if err := fixed.Append(&[]float64{1}); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("append to a fixed size dataset: got %v, want ErrOutOfRange", err)
+	}
+}
+
+func TestWriteSubset(t *testing.T) {
+	f := newFixture(t, "grid.h5")
+	w, err := NewHdfWriterSync("/grid", HdfWriteOptions{Dtype: reflect.Float64, Dims: []uint{3, 3}, File: f})
+	must(t, err)
+	defer w.Close()
+
+	if err := w.WriteSubsetFrom(&[]float64{1, 2, 3}, []int{1, 1}, []int{0, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteSubset(&HdfData{Buffer: &[]float64{7, 8, 9}}, []int{0, 2}, []int{2, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteSubsetFrom(&[]float64{1, 2}, []int{2, 3}, []int{0, 0}); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("write past the last row: got %v, want ErrOutOfRange", err)
+	}
+	if err := w.WriteSubsetFrom(&[]float64{1, 2, 3}, []int{0, 0}, []int{0, 1}); err == nil {
+		t.Error("expected an error writing 3 values to a 2 element selection")
+	}
+
+	dset, err := NewHdfDataset("/grid", HdfReadOptions{Dtype: reflect.Float64, File: f, ReadOnCreate: true})
+	must(t, err)
+	defer dset.Close()
+	want := []float64{
+		0, 0, 7,
+		1, 2, 8,
+		0, 0, 9,
+	}
+	if got := *dset.Data.Buffer.(*[]float64); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWriteInferredKind(t *testing.T) {
+	f := newFixture(t, "inferred.h5")
+
+	//Write creates the dataset from the HdfData dimensions and the buffer kind
+	w, err := NewHdfWriterSync("/data", HdfWriteOptions{File: f})
+	must(t, err)
+	err = w.Write(&HdfData{Dims: []uint{2, 2}, Buffer: &[]int16{1, -2, 3, -4}})
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Dims without Dtype defers creating the dataset to the first write
+	w, err = NewHdfWriterSync("/dims", HdfWriteOptions{Dims: []uint{2, 3}, File: f})
+	must(t, err)
+	err = w.WriteFrom(&[]uint32{1, 2, 3, 4, 5, 6})
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err = NewHdfWriterSync("/series", HdfWriteOptions{Dims: []uint{0, 2}, Extendable: true, File: f})
+	must(t, err)
+	err = w.Append(&[]float32{1, 2, 3, 4})
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		datapath string
+		kind     reflect.Kind
+		dims     []uint
+		want     interface{}
+	}{
+		{"/data", reflect.Int16, []uint{2, 2}, &[]int16{1, -2, 3, -4}},
+		{"/dims", reflect.Uint32, []uint{2, 3}, &[]uint32{1, 2, 3, 4, 5, 6}},
+		{"/series", reflect.Float32, []uint{2, 2}, &[]float32{1, 2, 3, 4}},
+	}
+	for _, tc := range tests {
+		dset, err := NewHdfDataset(tc.datapath, HdfReadOptions{Dtype: tc.kind, File: f, ReadOnCreate: true})
+		if err != nil {
+			t.Errorf("%s: %v", tc.datapath, err)
+			continue
+		}
+		if !reflect.DeepEqual(dset.Data.Buffer, tc.want) || !reflect.DeepEqual(dset.Data.Dims, tc.dims) {
+			t.Errorf("%s: got %v with dims %v, want %v with dims %v", tc.datapath, dset.Data.Buffer, dset.Data.Dims, tc.want, tc.dims)
+		}
+		dset.Close()
+	}
+}